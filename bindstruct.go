@@ -0,0 +1,246 @@
+// Copyright 2020 Howard C. Shaw III. All rights reserved.
+// Use of this source code is governed by the MIT-license
+// as defined in the LICENSE file.
+
+package renderview
+
+import (
+	"fmt"
+	"image/color"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+var colorType = reflect.TypeOf(color.RGBA{})
+var pointType = reflect.TypeOf(Point{})
+
+// BindStruct creates a RenderParameter for each exported field of the struct
+// v points to, and keeps each field set to its parameter's value, so render
+// code can read the struct (under the model lock) instead of the parameters.
+// Fields of type int, uint32, float64, complex128, string, bool,
+// color.RGBA, Point, []int, []float64 and []string are supported. Writing a field directly does not update its
+// parameter; set the parameter instead.
+//
+// The rv struct tag holds the parameter name followed by comma separated
+// options. The desc, label, unit and format tags set the parameter's
+// metadata (see META_LABEL):
+//
+//	MaxEsc int     `rv:"maxEsc,sidebar,min=1,max=1000,step=1" label:"Max escape" desc:"Iterations before giving up"`
+//	Scale  float64 `rv:"scale" unit:"px" format:"%.2f"`
+//	Mode   string  `rv:"mode,choices=fast|slow,group=Quality"`
+//	Input  string  `rv:"input,path,filter=*.gif|*.png"`
+//	Width  int     `rv:"width,hide"`
+//	Notes  string  `rv:"-"`
+//
+// The options are the hints hide, sidebar, footer, fulltext, readonly and
+// pick;
+// min, max and step, which bound an int or float64 field; choices, which
+// restricts a string field to a list separated by |; path, path=save or
+// path=dir, which make a string field a path, and filter, which limits
+// the files offered to globs separated by |; and group and order, which
+// place the parameter in the sidebar. Without a name the field name
+// is used with its first letter lowered. A tag of "-" skips the field.
+// A readonly field is an output: the renderer sets its parameter, and the
+// field follows like any other.
+func BindStruct(v interface{}) ([]RenderParameter, error) {
+	val := reflect.ValueOf(v)
+	if val.Kind() != reflect.Ptr || val.Elem().Kind() != reflect.Struct {
+		return nil, fmt.Errorf("renderview: BindStruct needs a pointer to a struct, not %T", v)
+	}
+	s := val.Elem()
+	t := s.Type()
+	params := make([]RenderParameter, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("rv")
+		if f.PkgPath != "" || tag == "-" {
+			continue
+		}
+		p, err := bindField(s.Field(i), f, tag)
+		if err != nil {
+			return nil, fmt.Errorf("renderview: field %v: %v", f.Name, err)
+		}
+		params = append(params, p)
+	}
+	return params, nil
+}
+
+// bindField creates the parameter for one field and subscribes the field to it
+func bindField(field reflect.Value, f reflect.StructField, tag string) (RenderParameter, error) {
+	opts := strings.Split(tag, ",")
+	name := opts[0]
+	if name == "" {
+		r, n := utf8.DecodeRuneInString(f.Name)
+		name = string(unicode.ToLower(r)) + f.Name[n:]
+	}
+	hint := 0
+	var min, max, step, group, path string
+	var choices, patterns []string
+	order := 0
+	for _, o := range opts[1:] {
+		key, value := o, ""
+		if i := strings.Index(o, "="); i >= 0 {
+			key, value = o[:i], o[i+1:]
+		}
+		switch key {
+		case "hide":
+			hint |= HINT_HIDE
+		case "sidebar":
+			hint |= HINT_SIDEBAR
+		case "footer":
+			hint |= HINT_FOOTER
+		case "fulltext":
+			hint |= HINT_FULLTEXT
+		case "readonly":
+			hint |= HINT_READONLY
+		case "pick":
+			hint |= HINT_PICK
+		case "min":
+			min = value
+		case "max":
+			max = value
+		case "step":
+			step = value
+		case "choices":
+			choices = strings.Split(value, "|")
+		case "path":
+			path = value
+			if path == "" {
+				path = "open"
+			}
+		case "filter":
+			patterns = strings.Split(value, "|")
+		case "group":
+			group = value
+		case "order":
+			n, err := strconv.Atoi(value)
+			if err != nil {
+				return nil, fmt.Errorf("order: %v", err)
+			}
+			order = n
+		default:
+			return nil, fmt.Errorf("unknown option %q", key)
+		}
+	}
+	bounded := min != "" || max != ""
+	if bounded && (min == "" || max == "") {
+		return nil, fmt.Errorf("min and max must be given together")
+	}
+
+	var p RenderParameter
+	switch {
+	case f.Type == colorType:
+		p = NewColorRP(name, field.Interface().(color.RGBA))
+	case f.Type == pointType:
+		pt := field.Interface().(Point)
+		p = NewPointRP(name, pt.X, pt.Y)
+	case len(choices) > 0:
+		if f.Type.Kind() != reflect.String {
+			return nil, fmt.Errorf("choices need a string field")
+		}
+		p = NewChoiceRP(name, field.String(), choices...)
+	case path != "":
+		if f.Type.Kind() != reflect.String {
+			return nil, fmt.Errorf("path needs a string field")
+		}
+		mode, err := ParsePathMode(path)
+		if err != nil {
+			return nil, err
+		}
+		p = NewPathRP(name, field.String(), mode, patterns...)
+	case f.Type.Kind() == reflect.Int:
+		if !bounded {
+			p = NewIntRP(name, int(field.Int()))
+			break
+		}
+		if step == "" {
+			step = "1"
+		}
+		b, err := parseInts(min, max, step)
+		if err != nil {
+			return nil, err
+		}
+		p = NewBoundedIntRP(name, int(field.Int()), b[0], b[1], b[2])
+	case f.Type.Kind() == reflect.Float64:
+		if !bounded {
+			p = NewFloat64RP(name, field.Float())
+			break
+		}
+		if step == "" {
+			step = "0"
+		}
+		b, err := parseFloats(min, max, step)
+		if err != nil {
+			return nil, err
+		}
+		p = NewBoundedFloat64RP(name, field.Float(), b[0], b[1], b[2])
+	case f.Type.Kind() == reflect.Uint32:
+		p = NewUInt32RP(name, uint32(field.Uint()))
+	case f.Type.Kind() == reflect.Complex128:
+		p = NewComplex128RP(name, field.Complex())
+	case f.Type.Kind() == reflect.String:
+		p = NewStringRP(name, field.String())
+	case f.Type.Kind() == reflect.Bool:
+		p = NewBoolRP(name, field.Bool())
+	case f.Type.Kind() == reflect.Slice && f.Type.Elem().Kind() == reflect.Int:
+		p = NewIntListRP(name, field.Convert(reflect.TypeOf([]int(nil))).Interface().([]int))
+	case f.Type.Kind() == reflect.Slice && f.Type.Elem().Kind() == reflect.Float64:
+		p = NewFloat64ListRP(name, field.Convert(reflect.TypeOf([]float64(nil))).Interface().([]float64))
+	case f.Type.Kind() == reflect.Slice && f.Type.Elem().Kind() == reflect.String:
+		p = NewStringListRP(name, field.Convert(reflect.TypeOf([]string(nil))).Interface().([]string))
+	default:
+		return nil, fmt.Errorf("unsupported type %v", f.Type)
+	}
+	if len(patterns) > 0 && path == "" {
+		return nil, fmt.Errorf("filter needs the path option")
+	}
+	if hint&HINT_PICK != 0 && p.GetType() != "complex128" && p.GetType() != "point" {
+		return nil, fmt.Errorf("pick needs a complex128 or Point field")
+	}
+	if bounded && p.GetType() != "int" && p.GetType() != "float64" {
+		return nil, fmt.Errorf("min and max need an int or float64 field")
+	}
+	p.SetHint(hint)
+	p.SetDescription(f.Tag.Get("desc"))
+	for _, key := range []string{META_LABEL, META_UNIT, META_FORMAT} {
+		if v, ok := f.Tag.Lookup(key); ok {
+			p.SetMeta(key, v)
+		}
+	}
+	p.SetGroup(group)
+	p.SetOrder(order)
+
+	// bounds or choices may have adjusted the initial value
+	field.Set(reflect.ValueOf(GetParameterValue(p)).Convert(f.Type))
+	p.OnChange(func(oldValue interface{}, newValue interface{}) {
+		field.Set(reflect.ValueOf(newValue).Convert(f.Type))
+	})
+	return p, nil
+}
+
+func parseInts(v ...string) ([]int, error) {
+	r := make([]int, len(v))
+	for i, s := range v {
+		n, err := strconv.Atoi(s)
+		if err != nil {
+			return nil, err
+		}
+		r[i] = n
+	}
+	return r, nil
+}
+
+func parseFloats(v ...string) ([]float64, error) {
+	r := make([]float64, len(v))
+	for i, s := range v {
+		n, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return nil, err
+		}
+		r[i] = n
+	}
+	return r, nil
+}
@@ -0,0 +1,327 @@
+// Copyright 2020 Howard C. Shaw III. All rights reserved.
+// Use of this source code is governed by the MIT-license
+// as defined in the LICENSE file.
+
+package renderview
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// viewParameters are always part of a bookmark
+var viewParameters = []string{"zoom", "left", "top", "right", "bottom"}
+
+// Bookmark is a named view: the viewport and zoom, plus the parameters
+// listed in Bookmarks.Include when it was added
+type Bookmark struct {
+	Name   string           `json:"name"`
+	Params []SavedParameter `json:"params"`
+}
+
+// Bookmarks is a model's list of named views. The list is kept in File,
+// which is read by Load and rewritten whenever a bookmark is added or
+// removed.
+//
+// Jump moves to a bookmark, animating the viewport over Duration; the
+// drivers call Step from a timer until it reports the jump is done.
+type Bookmarks struct {
+	sync.Mutex
+
+	// File holds the list between runs; empty keeps it in memory only
+	File string
+	// Include names the parameters saved along with the view
+	Include []string
+	// Duration is how long a jump takes
+	Duration time.Duration
+
+	model     RenderModel
+	list      []Bookmark
+	listeners []func()
+	jump      *bookmarkJump
+}
+
+// bookmarkJump interpolates the viewport from one view to another
+type bookmarkJump struct {
+	start    time.Time
+	from, to [4]float64
+	final    []SavedParameter
+}
+
+// BookmarkFile returns where bookmarks are kept by default, named after
+// the program under the user's configuration directory
+func BookmarkFile() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	name := strings.TrimSuffix(filepath.Base(os.Args[0]), filepath.Ext(os.Args[0]))
+	return filepath.Join(dir, "renderview", name+".bookmarks.json"), nil
+}
+
+// NewBookmarks creates an empty bookmark list for m, kept in BookmarkFile
+func NewBookmarks(m RenderModel) *Bookmarks {
+	b := &Bookmarks{
+		Duration: 600 * time.Millisecond,
+		model:    m,
+	}
+	b.File, _ = BookmarkFile()
+	return b
+}
+
+// OnChange registers f to be called after a bookmark is added or removed
+func (b *Bookmarks) OnChange(f func()) {
+	b.Lock()
+	defer b.Unlock()
+	b.listeners = append(b.listeners, f)
+}
+
+func (b *Bookmarks) notify() {
+	b.Lock()
+	listeners := b.listeners
+	b.Unlock()
+	for _, f := range listeners {
+		f()
+	}
+}
+
+// Load replaces the list with the one in File. A missing file is not an error.
+func (b *Bookmarks) Load() error {
+	b.Lock()
+	if b.File == "" {
+		b.Unlock()
+		return nil
+	}
+	data, err := ioutil.ReadFile(b.File)
+	if err != nil {
+		b.Unlock()
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	var list []Bookmark
+	if err = json.Unmarshal(data, &list); err != nil {
+		b.Unlock()
+		return fmt.Errorf("%v: %v", b.File, err)
+	}
+	b.list = list
+	b.Unlock()
+	b.notify()
+	return nil
+}
+
+// save writes the list to File; the caller holds the lock
+func (b *Bookmarks) save() error {
+	if b.File == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(b.list, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(filepath.Dir(b.File), 0755); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(b.File, data, 0644)
+}
+
+// Names returns the names of the bookmarks in the order they were added
+func (b *Bookmarks) Names() []string {
+	b.Lock()
+	defer b.Unlock()
+	names := make([]string, len(b.list))
+	for i, bm := range b.list {
+		names[i] = bm.Name
+	}
+	return names
+}
+
+// Get returns the named bookmark
+func (b *Bookmarks) Get(name string) (Bookmark, bool) {
+	b.Lock()
+	defer b.Unlock()
+	i := b.index(name)
+	if i < 0 {
+		return Bookmark{}, false
+	}
+	return b.list[i], true
+}
+
+func (b *Bookmarks) index(name string) int {
+	for i, bm := range b.list {
+		if bm.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// Add bookmarks the current view under name, replacing any bookmark
+// already using it, and saves the list
+func (b *Bookmarks) Add(name string) error {
+	if name == "" {
+		return fmt.Errorf("bookmark needs a name")
+	}
+	b.Lock()
+	include := make(map[string]bool)
+	for _, n := range viewParameters {
+		include[n] = true
+	}
+	for _, n := range b.Include {
+		include[n] = true
+	}
+	b.model.Lock()
+	state := parameterState(b.model)
+	b.model.Unlock()
+	bm := Bookmark{Name: name}
+	for _, s := range state {
+		if include[s.Name] {
+			bm.Params = append(bm.Params, s)
+		}
+	}
+	if i := b.index(name); i >= 0 {
+		b.list[i] = bm
+	} else {
+		b.list = append(b.list, bm)
+	}
+	err := b.save()
+	b.Unlock()
+	b.notify()
+	return err
+}
+
+// Remove deletes the named bookmark and saves the list
+func (b *Bookmarks) Remove(name string) error {
+	b.Lock()
+	i := b.index(name)
+	if i < 0 {
+		b.Unlock()
+		return nil
+	}
+	b.list = append(b.list[:i], b.list[i+1:]...)
+	err := b.save()
+	b.Unlock()
+	b.notify()
+	return err
+}
+
+// viewport reads left, top, right and bottom as float64; the caller holds the model lock
+func (b *Bookmarks) viewport() [4]float64 {
+	var v [4]float64
+	for i, name := range viewParameters[1:] {
+		v[i] = GetParameterValueAsFloat64(b.model.GetParameter(name))
+	}
+	return v
+}
+
+// Jump starts moving to the named bookmark. The other parameters and the
+// zoom are set at once, and the viewport follows over Duration as Step is
+// called. The view before the jump is recorded in the model's history.
+func (b *Bookmarks) Jump(name string) error {
+	bm, ok := b.Get(name)
+	if !ok {
+		return fmt.Errorf("no bookmark named %q", name)
+	}
+	b.model.GetHistory().Checkpoint("bookmark")
+
+	b.Lock()
+	defer b.Unlock()
+	b.model.Lock()
+	defer b.model.Unlock()
+
+	var others, view []SavedParameter
+	j := &bookmarkJump{
+		start: time.Now(),
+		from:  b.viewport(),
+	}
+	j.to = j.from
+	for _, s := range bm.Params {
+		k := indexOf(viewParameters[1:], s.Name)
+		if k < 0 {
+			others = append(others, s)
+			continue
+		}
+		view = append(view, s)
+		if f, err := strconv.ParseFloat(s.Value, 64); err == nil {
+			j.to[k] = f
+		}
+	}
+	j.final = view
+	if err := applyState(b.model, others); err != nil {
+		return err
+	}
+	if b.Duration <= 0 {
+		b.jump = nil
+		return applyState(b.model, view)
+	}
+	b.jump = j
+	return nil
+}
+
+func indexOf(names []string, name string) int {
+	for i, n := range names {
+		if n == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// Jumping reports whether a jump is still in progress
+func (b *Bookmarks) Jumping() bool {
+	b.Lock()
+	defer b.Unlock()
+	return b.jump != nil
+}
+
+// Step moves the viewport to where the current jump should be by now,
+// reporting whether it changed anything. The last step lands exactly on
+// the bookmark and ends the jump.
+func (b *Bookmarks) Step() bool {
+	b.Lock()
+	defer b.Unlock()
+	j := b.jump
+	if j == nil {
+		return false
+	}
+	b.model.Lock()
+	defer b.model.Unlock()
+
+	t := float64(time.Since(j.start)) / float64(b.Duration)
+	if t >= 1 {
+		b.jump = nil
+		applyState(b.model, j.final)
+		return true
+	}
+	// ease in and out
+	t = t * t * (3 - 2*t)
+	x0, x1 := interpolateSpan(j.from[0], j.from[2], j.to[0], j.to[2], t)
+	y0, y1 := interpolateSpan(j.from[1], j.from[3], j.to[1], j.to[3], t)
+	for i, v := range [4]float64{x0, y0, x1, y1} {
+		SetParameterValueFromFloat64(b.model.GetParameter(viewParameters[i+1]), v)
+	}
+	return true
+}
+
+// interpolateSpan moves the center of a span linearly and its size
+// geometrically, so zooming in and out proceeds at an even pace
+func interpolateSpan(a0, b0, a1, b1, t float64) (float64, float64) {
+	c := (a0+b0)/2 + ((a1+b1)/2-(a0+b0)/2)*t
+	s0, s1 := b0-a0, b1-a1
+	var s float64
+	if s0*s1 > 0 {
+		s = s0 * math.Pow(s1/s0, t)
+	} else {
+		s = s0 + (s1-s0)*t
+	}
+	return c - s/2, c + s/2
+}
@@ -6,11 +6,11 @@ package renderview
 
 import "sync"
 
-// ChangeMonitor implements comparing the value
-// of a subset of parameters to determine whether
-// a redraw or recalculation is required
+// ChangeMonitor subscribes to a subset of parameters
+// to determine whether a redraw or recalculation
+// is required
 type ChangeMonitor struct {
-	lastValue string
+	changed bool
 
 	sync.Mutex
 	Params []RenderParameter
@@ -18,27 +18,37 @@ type ChangeMonitor struct {
 
 func NewChangeMonitor() *ChangeMonitor {
 	return &ChangeMonitor{
-		lastValue: "",
-		Params:    make([]RenderParameter, 0, 10),
+		changed: true,
+		Params:  make([]RenderParameter, 0, 10),
 	}
 }
 
+// AddParameters subscribes the monitor to params. Parameters must be
+// added through here rather than appended to Params to be observed.
 func (c *ChangeMonitor) AddParameters(params ...RenderParameter) {
 	c.Lock()
 	defer c.Unlock()
 
 	c.Params = append(c.Params, params...)
+	for _, p := range params {
+		p.OnChange(c.markChanged)
+	}
 }
 
+func (c *ChangeMonitor) markChanged(oldValue interface{}, newValue interface{}) {
+	c.Lock()
+	defer c.Unlock()
+
+	c.changed = true
+}
+
+// HasChanged reports whether any monitored parameter has changed since
+// the last call. The first call always returns true.
 func (c *ChangeMonitor) HasChanged() bool {
 	c.Lock()
 	defer c.Unlock()
 
-	newValue := GetParameterStatusString(c.Params...)
-	//fmt.Printf("Comparing (%v) to (%v)\n", c.lastValue, newValue)
-	if c.lastValue != newValue {
-		c.lastValue = newValue
-		return true
-	}
-	return false
+	changed := c.changed
+	c.changed = false
+	return changed
 }
@@ -26,7 +26,7 @@ import (
 var (
 	defaultFlags = flag.Bool("defaultflags", true, "include default flags (left, top, right, bottom, width, height, options)")
 	extraFlags   = flag.String("extraflags", "", "quoted comma-separated list, name, type, and starting value, e.g. \"left,float64,0,top,float64,0\"")
-	watchFile    = flag.String("watch", "", "if defined, image file to read in after executing command")
+	watchFile    = flag.String("watch", "", "if defined, image file to read in after executing command; it can be changed from the sidebar")
 )
 
 func main() {
@@ -49,23 +49,26 @@ func main() {
 				rv.NewIntRP("options", rv.OPT_AUTO_ZOOM))...)
 	}
 	m.AddParameters(rv.SetHints(rv.HINT_SIDEBAR, createExtraFlags(*extraFlags)...)...)
+	if *watchFile != "" {
+		m.AddParameters(rv.SetHints(rv.HINT_SIDEBAR,
+			rv.NewPathRP("watch", *watchFile, rv.PATH_OPEN, "*.png", "*.gif", "*.jpg", "*.jpeg"))...)
+	}
 	m.InnerRender = getInnerRender(m, cmd, argTemplate)
-	m.InnerRender()
+	m.InnerRender(m.Snapshot())
 	driver.Main(m)
 }
 
-func getInnerRender(m *rv.BasicRenderModel, cmd string, argtemplate *template.Template) func() {
-	return func() {
-		InnerRender(m, cmd, argtemplate)
+func getInnerRender(m *rv.BasicRenderModel, cmd string, argtemplate *template.Template) func(rv.Snapshot) {
+	return func(s rv.Snapshot) {
+		InnerRender(m, s, cmd, argtemplate)
 	}
 }
 
-func InnerRender(m *rv.BasicRenderModel, cmd string, argtemplate *template.Template) {
-	flags := m.GetParameterNames()
+func InnerRender(m *rv.BasicRenderModel, s rv.Snapshot, cmd string, argtemplate *template.Template) {
+	flags := s.Names()
 	templateMap := make(map[string]string)
 	for _, k := range flags {
-		p := m.GetParameter(k)
-		v := rv.GetParameterValueAsString(p)
+		v := s.String(k)
 		templateMap[k] = v
 		os.Setenv(k, v)
 	}
@@ -77,7 +80,7 @@ func InnerRender(m *rv.BasicRenderModel, cmd string, argtemplate *template.Templ
 	args := parseArgs(argresult.String())
 	//fmt.Printf("args: %v\n", args)
 
-	wf := *watchFile
+	wf := s.String("watch")
 	command := exec.Command(cmd, args...)
 	//fmt.Printf("command: %v\nwf: %v\nwf == \"\": %v\n", command, wf, wf == "")
 	if wf == "" {
@@ -94,7 +97,9 @@ func InnerRender(m *rv.BasicRenderModel, cmd string, argtemplate *template.Templ
 		if err != nil {
 			return
 		}
+		m.Lock()
 		m.Img = img
+		m.Unlock()
 	} else {
 		command.Run()
 		f, err := os.Open(wf)
@@ -108,7 +113,9 @@ func InnerRender(m *rv.BasicRenderModel, cmd string, argtemplate *template.Templ
 		if err != nil {
 			return
 		}
+		m.Lock()
 		m.Img = img
+		m.Unlock()
 	}
 }
 
@@ -162,7 +169,7 @@ func createExtraFlags(extraflags string) []rv.RenderParameter {
 	for i := 0; i < (len(split) / 3); i++ {
 		switch split[i*3+1] {
 		case "int":
-			iv, err := strconv.Atoi(split[i*3+2])
+			iv, err := rv.ParseIntExpression(split[i*3+2])
 			handleError(err)
 			flag := rv.NewIntRP(split[i*3], iv)
 			flags = append(flags, flag)
@@ -172,15 +179,24 @@ func createExtraFlags(extraflags string) []rv.RenderParameter {
 			flag := rv.NewUInt32RP(split[i*3], uint32(iv))
 			flags = append(flags, flag)
 		case "float64":
-			fv, err := strconv.ParseFloat(split[i*3+2], 64)
+			fv, err := rv.ParseFloatExpression(split[i*3+2])
 			handleError(err)
 			flag := rv.NewFloat64RP(split[i*3], fv)
 			flags = append(flags, flag)
 		case "complex128":
-			cv, err := rv.ParseComplex(split[i*3+2])
+			cv, err := rv.ParseComplexExpression(split[i*3+2])
 			handleError(err)
 			flag := rv.NewComplex128RP(split[i*3], cv)
 			flags = append(flags, flag)
+		case "path", "savepath", "dirpath":
+			mode := rv.PATH_OPEN
+			if split[i*3+1] == "savepath" {
+				mode = rv.PATH_SAVE
+			} else if split[i*3+1] == "dirpath" {
+				mode = rv.PATH_DIRECTORY
+			}
+			flag := rv.NewPathRP(split[i*3], split[i*3+2], mode)
+			flags = append(flags, flag)
 		default:
 			flag := rv.NewStringRP(split[i*3], split[i*3+2])
 			flags = append(flags, flag)
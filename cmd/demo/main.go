@@ -5,12 +5,39 @@
 package main
 
 import (
+	"flag"
+	"log"
+
+	rv "github.com/TheGrum/renderview"
 	"github.com/TheGrum/renderview/driver"
 
 	"github.com/TheGrum/renderview/examples/mandelbrot"
 )
 
+var (
+	timeline = flag.String("timeline", "", "animation timeline to load, as saved by Timeline.Save")
+	export   = flag.String("export", "", "if defined, render the timeline to this file without opening a window; a .gif name gives an animated GIF, any other numbered PNG files")
+	width    = flag.Int("width", 640, "width of exported frames")
+	height   = flag.Int("height", 480, "height of exported frames")
+)
+
 func main() {
+	flag.Parse()
 	m := mandelbrot.NewMandelModel()
+	if *timeline != "" {
+		if err := m.GetTimeline().Load(*timeline); err != nil {
+			log.Fatal(err)
+		}
+	}
+	if *export != "" {
+		m.Lock()
+		rv.SetParameterValue(m.GetParameter("width"), *width)
+		rv.SetParameterValue(m.GetParameter("height"), *height)
+		m.Unlock()
+		if err := m.GetTimeline().Export(*export); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
 	driver.Main(m)
 }
@@ -0,0 +1,67 @@
+// Copyright 2020 Howard C. Shaw III. All rights reserved.
+// Use of this source code is governed by the MIT-license
+// as defined in the LICENSE file.
+
+// sweep renders the Mandelbrot demo over a range of one or two of its
+// parameters without opening a window, writing each image and a labeled
+// contact sheet, for example
+//
+//	sweep -x maxEsc=20:100:20 -y left=-2:-1:0.5 -sheet sheet.png
+package main
+
+import (
+	"flag"
+	"log"
+
+	rv "github.com/TheGrum/renderview"
+
+	"github.com/TheGrum/renderview/examples/mandelbrot"
+)
+
+var (
+	xAxis  = flag.String("x", "", "first parameter and range, as name=from:to:step; it varies down the contact sheet")
+	yAxis  = flag.String("y", "", "optional second parameter and range, varying across the contact sheet")
+	params = flag.String("params", "", "parameter file to load before sweeping, as saved by the demo")
+	dir    = flag.String("dir", "", "if defined, directory to write each image to")
+	sheet  = flag.String("sheet", "sheet.png", "contact sheet to write, or empty for none")
+	width  = flag.Int("width", 160, "width of each image")
+	height = flag.Int("height", 120, "height of each image")
+)
+
+func main() {
+	flag.Parse()
+	if *xAxis == "" {
+		flag.Usage()
+		return
+	}
+	s := &rv.Sweep{
+		Dir:   *dir,
+		Sheet: *sheet,
+	}
+	for _, a := range []string{*xAxis, *yAxis} {
+		if a == "" {
+			continue
+		}
+		axis, err := rv.ParseSweepAxis(a)
+		if err != nil {
+			log.Fatal(err)
+		}
+		s.Axes = append(s.Axes, axis)
+	}
+
+	m := mandelbrot.NewMandelModel()
+	// there is no view to paint, but loading a zoom asks for a paint
+	m.SetRequestPaintFunc(func() {})
+	if *params != "" {
+		if err := rv.LoadParametersFromFile(*params, m); err != nil {
+			log.Fatal(err)
+		}
+	}
+	m.Lock()
+	rv.SetParameterValue(m.GetParameter("width"), *width)
+	rv.SetParameterValue(m.GetParameter("height"), *height)
+	m.Unlock()
+	if err := s.Run(m); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,151 @@
+// Copyright 2020 Howard C. Shaw III. All rights reserved.
+// Use of this source code is governed by the MIT-license
+// as defined in the LICENSE file.
+
+package renderview
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DeriveFunc computes a derived parameter's value from the values of its
+// inputs, given in the order they were named and in their native types
+// (see GetParameterValue). The result is stored with SetParameterValue.
+type DeriveFunc func(inputs ...interface{}) interface{}
+
+type derivation struct {
+	target string
+	inputs []string
+	f      DeriveFunc
+}
+
+// Derivations keeps derived parameters up to date with their inputs.
+// When an input changes, every parameter depending on it, directly or
+// through other derived parameters, is recomputed in dependency order.
+type Derivations struct {
+	model RenderModel
+	// list is kept in dependency order, each after those it reads
+	list     []*derivation
+	watched  map[string]bool
+	updating bool
+}
+
+// NewDerivations creates an empty set of derived parameters for m
+func NewDerivations(m RenderModel) *Derivations {
+	return &Derivations{
+		model:   m,
+		watched: make(map[string]bool),
+	}
+}
+
+// Add makes target follow f applied to the named inputs, and computes it
+// at once. It fails if a parameter is missing, if target is already
+// derived, or if the new derivation would make a parameter depend on itself.
+func (d *Derivations) Add(target string, inputs []string, f DeriveFunc) error {
+	for _, name := range append([]string{target}, inputs...) {
+		if d.model.GetParameter(name).GetName() != name {
+			return fmt.Errorf("renderview: no parameter named %q", name)
+		}
+	}
+	for _, dv := range d.list {
+		if dv.target == target {
+			return fmt.Errorf("renderview: %v is already derived", target)
+		}
+	}
+	dv := &derivation{target: target, inputs: inputs, f: f}
+	list, err := sortDerivations(append(d.list, dv))
+	if err != nil {
+		return err
+	}
+	d.list = list
+	for _, name := range inputs {
+		if d.watched[name] {
+			continue
+		}
+		d.watched[name] = true
+		name := name
+		d.model.GetParameter(name).OnChange(func(oldValue interface{}, newValue interface{}) {
+			d.Update(name)
+		})
+	}
+	return d.evaluate(dv)
+}
+
+// Update recomputes the parameters depending on the named one. It is
+// called when an input changes, and does nothing while an update is
+// already under way, as that update reaches the same parameters.
+func (d *Derivations) Update(changed string) {
+	if d.updating {
+		return
+	}
+	d.updating = true
+	defer func() { d.updating = false }()
+	dirty := map[string]bool{changed: true}
+	for _, dv := range d.list {
+		for _, name := range dv.inputs {
+			if dirty[name] {
+				d.evaluate(dv)
+				dirty[dv.target] = true
+				break
+			}
+		}
+	}
+}
+
+func (d *Derivations) evaluate(dv *derivation) error {
+	values := make([]interface{}, len(dv.inputs))
+	for i, name := range dv.inputs {
+		values[i] = GetParameterValue(d.model.GetParameter(name))
+	}
+	return SetParameterValue(d.model.GetParameter(dv.target), dv.f(values...))
+}
+
+// sortDerivations orders list so each derivation follows those computing
+// its inputs, or reports the first cycle found
+func sortDerivations(list []*derivation) ([]*derivation, error) {
+	byTarget := make(map[string]*derivation)
+	for _, dv := range list {
+		byTarget[dv.target] = dv
+	}
+	const (
+		visiting = 1
+		done     = 2
+	)
+	state := make(map[string]int)
+	sorted := make([]*derivation, 0, len(list))
+	var path []string
+	var visit func(dv *derivation) error
+	visit = func(dv *derivation) error {
+		switch state[dv.target] {
+		case done:
+			return nil
+		case visiting:
+			for i, name := range path {
+				if name == dv.target {
+					cycle := append(path[i:], dv.target)
+					return fmt.Errorf("renderview: derived parameters form a cycle: %v", strings.Join(cycle, " -> "))
+				}
+			}
+		}
+		state[dv.target] = visiting
+		path = append(path, dv.target)
+		for _, name := range dv.inputs {
+			if in, ok := byTarget[name]; ok {
+				if err := visit(in); err != nil {
+					return err
+				}
+			}
+		}
+		path = path[:len(path)-1]
+		state[dv.target] = done
+		sorted = append(sorted, dv)
+		return nil
+	}
+	for _, dv := range list {
+		if err := visit(dv); err != nil {
+			return nil, err
+		}
+	}
+	return sorted, nil
+}
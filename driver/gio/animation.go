@@ -0,0 +1,158 @@
+// Copyright 2020 Howard C. Shaw III. All rights reserved.
+// Use of this source code is governed by the MIT-license
+// as defined in the LICENSE file.
+
+// +build gio
+
+package gio
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+
+	rv "github.com/TheGrum/renderview"
+
+	"gioui.org/layout"
+	"gioui.org/unit"
+	"gioui.org/widget"
+	"gioui.org/widget/material"
+)
+
+// AnimationPanel is the sidebar's keyframe animation controls. Add
+// keyframe records the current parameters the seconds in the editor after
+// the last keyframe; Play runs the timeline in the view, Open and Save
+// read and write it at rv.DefaultTimelineFile, and Export renders it to
+// rv.DefaultAnimationFile.
+type AnimationPanel struct {
+	Section
+
+	T *rv.Timeline
+
+	Gap    *widget.Editor
+	Add    *widget.Button
+	Play   *widget.Button
+	Clear  *widget.Button
+	Open   *widget.Button
+	Save   *widget.Button
+	Export *widget.Button
+}
+
+// NewAnimationPanel builds a panel for the model's timeline
+func NewAnimationPanel(r rv.RenderModel) *AnimationPanel {
+	a := &AnimationPanel{
+		Section: Section{Title: "Animation"},
+		T:       r.GetTimeline(),
+		Gap: &widget.Editor{
+			SingleLine: true,
+			Submit:     true,
+		},
+		Add:    new(widget.Button),
+		Play:   new(widget.Button),
+		Clear:  new(widget.Button),
+		Open:   new(widget.Button),
+		Save:   new(widget.Button),
+		Export: new(widget.Button),
+	}
+	a.Gap.SetText("1")
+	return a
+}
+
+// Widgets returns the panel's rows for the sidebar list. The rows call
+// changed when playback starts, so the window keeps stepping it.
+func (a *AnimationPanel) Widgets(gtx *layout.Context, th *material.Theme, changed func()) []func() {
+	rows := []func(){
+		func() {
+			a.LayoutHeader(gtx, th)
+		},
+	}
+	if a.Collapsed {
+		return rows
+	}
+	button := func(b *widget.Button, label string) layout.FlexChild {
+		return layout.Flexed(1, func() {
+			layout.Inset{Right: unit.Dp(2)}.Layout(gtx, func() {
+				th.Button(label).Layout(gtx, b)
+			})
+		})
+	}
+	rows = append(rows,
+		func() {
+			th.Label(unit.Dp(14), fmt.Sprintf("%d keyframes, %gs", len(a.T.Keyframes()), a.T.Duration())).Layout(gtx)
+		},
+		func() {
+			add := false
+			for _, e := range a.Gap.Events(gtx) {
+				if _, ok := e.(widget.SubmitEvent); ok {
+					add = true
+				}
+			}
+			for a.Add.Clicked(gtx) {
+				add = true
+			}
+			if add {
+				gap, err := strconv.ParseFloat(a.Gap.Text(), 64)
+				if err == nil {
+					err = a.T.AppendKeyframe(gap)
+				}
+				if err != nil {
+					log.Printf("renderview: %v", err)
+				}
+			}
+			layout.Flex{Alignment: layout.Middle}.Layout(gtx,
+				layout.Flexed(0.3, func() {
+					th.Editor("seconds").Layout(gtx, a.Gap)
+				}),
+				button(a.Add, "Add keyframe"))
+		},
+		func() {
+			for a.Play.Clicked(gtx) {
+				if a.T.Playing() {
+					a.T.Stop()
+				} else if err := a.T.Play(); err != nil {
+					log.Printf("renderview: %v", err)
+				} else {
+					changed()
+				}
+			}
+			for a.Clear.Clicked(gtx) {
+				a.T.Clear()
+			}
+			play := "Play"
+			if a.T.Playing() {
+				play = "Stop"
+			}
+			layout.Flex{}.Layout(gtx,
+				button(a.Play, play),
+				button(a.Clear, "Clear"))
+		},
+		func() {
+			for a.Open.Clicked(gtx) {
+				if err := a.T.Load(rv.DefaultTimelineFile()); err != nil {
+					log.Printf("renderview: loading timeline: %v", err)
+				}
+			}
+			for a.Save.Clicked(gtx) {
+				filename := rv.DefaultTimelineFile()
+				if err := a.T.Save(filename); err != nil {
+					log.Printf("renderview: saving timeline: %v", err)
+				} else {
+					log.Printf("renderview: timeline saved to %v", filename)
+				}
+			}
+			for a.Export.Clicked(gtx) {
+				filename := rv.DefaultAnimationFile()
+				if err := a.T.Export(filename); err != nil {
+					log.Printf("renderview: exporting animation: %v", err)
+				} else {
+					log.Printf("renderview: animation exported to %v", filename)
+				}
+				changed()
+			}
+			layout.Flex{}.Layout(gtx,
+				button(a.Open, "Open"),
+				button(a.Save, "Save"),
+				button(a.Export, "Export"))
+		})
+	return rows
+}
@@ -0,0 +1,124 @@
+// Copyright 2020 Howard C. Shaw III. All rights reserved.
+// Use of this source code is governed by the MIT-license
+// as defined in the LICENSE file.
+
+// +build gio
+
+package gio
+
+import (
+	"log"
+
+	rv "github.com/TheGrum/renderview"
+
+	"gioui.org/layout"
+	"gioui.org/widget"
+	"gioui.org/widget/material"
+)
+
+// BookmarkPanel is the sidebar's presets list: a button per bookmark
+// that jumps to it, and an editor with buttons to add the current view
+// or remove the bookmark last jumped to.
+type BookmarkPanel struct {
+	Section
+
+	B *rv.Bookmarks
+
+	Name   *widget.Editor
+	Add    *widget.Button
+	Remove *widget.Button
+
+	buttons map[string]*widget.Button
+	chosen  string
+}
+
+// NewBookmarkPanel loads the model's bookmarks and builds a panel for them
+func NewBookmarkPanel(r rv.RenderModel) *BookmarkPanel {
+	b := &BookmarkPanel{
+		Section: Section{Title: "Bookmarks"},
+		B:       r.GetBookmarks(),
+		Name: &widget.Editor{
+			SingleLine: true,
+			Submit:     true,
+		},
+		Add:     new(widget.Button),
+		Remove:  new(widget.Button),
+		buttons: make(map[string]*widget.Button),
+	}
+	if err := b.B.Load(); err != nil {
+		log.Printf("renderview: loading bookmarks: %v", err)
+	}
+	return b
+}
+
+// Widgets returns the panel's rows for the sidebar list. The rows call
+// changed when a jump starts, so the window keeps stepping it.
+func (b *BookmarkPanel) Widgets(gtx *layout.Context, th *material.Theme, changed func()) []func() {
+	rows := []func(){
+		func() {
+			b.LayoutHeader(gtx, th)
+		},
+	}
+	if b.Collapsed {
+		return rows
+	}
+	for _, name := range b.B.Names() {
+		btn, ok := b.buttons[name]
+		if !ok {
+			btn = new(widget.Button)
+			b.buttons[name] = btn
+		}
+		rows = append(rows, func(name string, btn *widget.Button) func() {
+			return func() {
+				for btn.Clicked(gtx) {
+					if err := b.B.Jump(name); err != nil {
+						log.Printf("renderview: %v", err)
+						continue
+					}
+					b.chosen = name
+					changed()
+				}
+				bt := th.Button(name)
+				if name != b.chosen {
+					bt.Background = th.Color.Hint
+				}
+				bt.Layout(gtx, btn)
+			}
+		}(name, btn))
+	}
+	rows = append(rows,
+		func() {
+			for b.Remove.Clicked(gtx) {
+				if err := b.B.Remove(b.chosen); err != nil {
+					log.Printf("renderview: saving bookmarks: %v", err)
+				}
+				delete(b.buttons, b.chosen)
+				b.chosen = ""
+			}
+			th.Button("Remove").Layout(gtx, b.Remove)
+		},
+		func() {
+			th.Editor("bookmark name").Layout(gtx, b.Name)
+		},
+		func() {
+			add := false
+			for _, e := range b.Name.Events(gtx) {
+				if _, ok := e.(widget.SubmitEvent); ok {
+					add = true
+				}
+			}
+			for b.Add.Clicked(gtx) {
+				add = true
+			}
+			if add {
+				if err := b.B.Add(b.Name.Text()); err != nil {
+					log.Printf("renderview: %v", err)
+				} else {
+					b.chosen = b.Name.Text()
+					b.Name.SetText("")
+				}
+			}
+			th.Button("Add").Layout(gtx, b.Add)
+		})
+	return rows
+}
@@ -0,0 +1,39 @@
+// Copyright 2020 Howard C. Shaw III. All rights reserved.
+// Use of this source code is governed by the MIT-license
+// as defined in the LICENSE file.
+
+// +build gio
+
+package gio
+
+import (
+	"gioui.org/layout"
+	"gioui.org/unit"
+	"gioui.org/widget/material"
+)
+
+// FOOTER_CELL_WIDTH is the width in Dp of each label and editor pair in the footer
+const FOOTER_CELL_WIDTH = 140
+
+// LayoutFooter draws the footer parameters side by side across the
+// available width, reporting whether the user changed any of them
+func LayoutFooter(gtx *layout.Context, th *material.Theme, h *Hover, editors []*ParamEdit) bool {
+	changed := false
+	cells := make([]layout.FlexChild, len(editors))
+	for i, pe := range editors {
+		pe := pe
+		cells[i] = layout.Rigid(func() {
+			if w := gtx.Px(unit.Dp(FOOTER_CELL_WIDTH)); w < gtx.Constraints.Width.Max {
+				gtx.Constraints.Width.Max = w
+			}
+			gtx.Constraints.Width.Min = 0
+			layout.UniformInset(unit.Dp(2)).Layout(gtx, func() {
+				if pe.Layout(gtx, th, h) {
+					changed = true
+				}
+			})
+		})
+	}
+	layout.Flex{}.Layout(gtx, cells...)
+	return changed
+}
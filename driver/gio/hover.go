@@ -0,0 +1,67 @@
+// Copyright 2020 Howard C. Shaw III. All rights reserved.
+// Use of this source code is governed by the MIT-license
+// as defined in the LICENSE file.
+
+// +build gio
+
+package gio
+
+import (
+	"image"
+	"time"
+
+	"gioui.org/io/pointer"
+	"gioui.org/layout"
+	"gioui.org/op"
+)
+
+// Hover works out which HoverAreas the pointer is over, standing in for
+// enter and leave events. Layout registers it over the whole window,
+// passing events through, so it sees every move; an area is under the
+// pointer when the last move it saw is the last move of all.
+type Hover struct {
+	last time.Duration
+}
+
+// Update reads the moves since the last frame; call it before laying out
+// any HoverArea
+func (h *Hover) Update(gtx *layout.Context) {
+	for _, ev := range gtx.Events(h) {
+		if e, ok := ev.(pointer.Event); ok {
+			h.last = e.Time
+		}
+	}
+}
+
+// Layout registers h over an area of the given size; call it after
+// everything else so it is on top
+func (h *Hover) Layout(gtx *layout.Context, size image.Point) {
+	var stack op.StackOp
+	stack.Push(gtx.Ops)
+	pointer.PassOp{Pass: true}.Add(gtx.Ops)
+	pointer.Rect(image.Rectangle{Max: size}).Add(gtx.Ops)
+	pointer.InputOp{Key: h}.Add(gtx.Ops)
+	stack.Pop()
+}
+
+// HoverArea tracks whether the pointer is over a widget
+type HoverArea struct {
+	last time.Duration
+}
+
+// Layout draws w and registers the area it covers, reporting whether
+// the pointer was over it at the last move
+func (a *HoverArea) Layout(gtx *layout.Context, h *Hover, w func()) bool {
+	for _, ev := range gtx.Events(a) {
+		if e, ok := ev.(pointer.Event); ok {
+			a.last = e.Time
+		}
+	}
+	w()
+	var stack op.StackOp
+	stack.Push(gtx.Ops)
+	pointer.Rect(image.Rectangle{Max: gtx.Dimensions.Size}).Add(gtx.Ops)
+	pointer.InputOp{Key: a}.Add(gtx.Ops)
+	stack.Pop()
+	return a.last != 0 && a.last == h.last
+}
@@ -0,0 +1,106 @@
+// Copyright 2020 Howard C. Shaw III. All rights reserved.
+// Use of this source code is governed by the MIT-license
+// as defined in the LICENSE file.
+
+// +build gio
+
+package gio
+
+import (
+	rv "github.com/TheGrum/renderview"
+
+	"gioui.org/layout"
+	"gioui.org/unit"
+	"gioui.org/widget"
+	"gioui.org/widget/material"
+)
+
+// ListEdit edits the items of a list parameter, a row per item of an
+// editor with buttons to move the item up or down or remove it, above a
+// button adding an item
+type ListEdit struct {
+	Editors []*widget.Editor
+
+	up, down, remove []*widget.Button
+	add              widget.Button
+}
+
+// Items returns the text of the editors
+func (l *ListEdit) Items() []string {
+	items := make([]string, len(l.Editors))
+	for i, e := range l.Editors {
+		items[i] = e.Text()
+	}
+	return items
+}
+
+// SetItems sets an editor per item
+func (l *ListEdit) SetItems(items []string) {
+	for len(l.Editors) < len(items) {
+		l.Editors = append(l.Editors, &widget.Editor{
+			SingleLine: true,
+			Submit:     true,
+		})
+		l.up = append(l.up, new(widget.Button))
+		l.down = append(l.down, new(widget.Button))
+		l.remove = append(l.remove, new(widget.Button))
+	}
+	l.Editors = l.Editors[:len(items)]
+	for i, item := range items {
+		l.Editors[i].SetText(item)
+	}
+}
+
+// Layout draws the rows and the add button. It returns the items as
+// edited, and true, when a button was clicked or an item submitted.
+func (l *ListEdit) Layout(gtx *layout.Context, th *material.Theme, p rv.RenderParameter) (items []string, edited bool) {
+	rows := make([]layout.FlexChild, 0, len(l.Editors)+1)
+	for i := range l.Editors {
+		i := i
+		rows = append(rows, layout.Rigid(func() {
+			button := func(b *widget.Button, label string, f func(l []string) []string) layout.FlexChild {
+				return layout.Rigid(func() {
+					for b.Clicked(gtx) {
+						items, edited = f(l.Items()), true
+					}
+					layout.Inset{Left: unit.Dp(2)}.Layout(gtx, func() {
+						th.Button(label).Layout(gtx, b)
+					})
+				})
+			}
+			layout.Flex{Alignment: layout.Middle}.Layout(gtx,
+				layout.Flexed(1, func() {
+					th.Editor("").Layout(gtx, l.Editors[i])
+					for _, e := range l.Editors[i].Events(gtx) {
+						if _, ok := e.(widget.SubmitEvent); ok {
+							items, edited = l.Items(), true
+						}
+					}
+				}),
+				button(l.up[i], "↑", func(l []string) []string {
+					if i > 0 {
+						l[i-1], l[i] = l[i], l[i-1]
+					}
+					return l
+				}),
+				button(l.down[i], "↓", func(l []string) []string {
+					if i < len(l)-1 {
+						l[i], l[i+1] = l[i+1], l[i]
+					}
+					return l
+				}),
+				button(l.remove[i], "×", func(l []string) []string {
+					return append(l[:i], l[i+1:]...)
+				}))
+		}))
+	}
+	rows = append(rows, layout.Rigid(func() {
+		for l.add.Clicked(gtx) {
+			items = l.Items()
+			items, edited = append(items, rv.NewListItem(p, items)), true
+		}
+		th.Button("Add").Layout(gtx, &l.add)
+	}))
+	layout.Flex{Axis: layout.Vertical}.Layout(gtx, rows...)
+	return items, edited
+}
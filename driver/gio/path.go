@@ -0,0 +1,76 @@
+// Copyright 2020 Howard C. Shaw III. All rights reserved.
+// Use of this source code is governed by the MIT-license
+// as defined in the LICENSE file.
+
+// +build gio
+
+package gio
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	rv "github.com/TheGrum/renderview"
+
+	"gioui.org/layout"
+	"gioui.org/widget"
+	"gioui.org/widget/material"
+)
+
+// MAX_COMPLETIONS limits the paths offered below a path editor
+const MAX_COMPLETIONS = 8
+
+// PathCompletion offers the paths that could complete the text of a
+// path parameter's editor, as buttons below it
+type PathCompletion struct {
+	matches []string
+	buttons []*widget.Button
+}
+
+// Update lists the completions of text for the path parameter p
+func (c *PathCompletion) Update(text string, p rv.RenderParameter) {
+	c.matches = nil
+	if text == "" {
+		return
+	}
+	mode, patterns := p.GetPathOptions()
+	c.matches = rv.CompletePath(text, mode, patterns)
+	if len(c.matches) == 1 && c.matches[0] == text {
+		// already complete
+		c.matches = nil
+	}
+	if len(c.matches) > MAX_COMPLETIONS {
+		c.matches = c.matches[:MAX_COMPLETIONS]
+	}
+	for len(c.buttons) < len(c.matches) {
+		c.buttons = append(c.buttons, new(widget.Button))
+	}
+}
+
+// Layout draws a button per completion, returning the one clicked, if
+// any, and whether it names a file rather than a directory to descend into
+func (c *PathCompletion) Layout(gtx *layout.Context, th *material.Theme) (chosen string, file bool) {
+	if len(c.matches) == 0 {
+		gtx.Dimensions = layout.Dimensions{}
+		return "", false
+	}
+	rows := make([]layout.FlexChild, len(c.matches))
+	for i, m := range c.matches {
+		i, m := i, m
+		rows[i] = layout.Rigid(func() {
+			for c.buttons[i].Clicked(gtx) {
+				chosen = m
+			}
+			name := filepath.Base(m)
+			if strings.HasSuffix(m, string(os.PathSeparator)) {
+				name += string(os.PathSeparator)
+			}
+			b := th.Button(name)
+			b.Background = th.Color.Hint
+			b.Layout(gtx, c.buttons[i])
+		})
+	}
+	layout.Flex{Axis: layout.Vertical}.Layout(gtx, rows...)
+	return chosen, chosen != "" && !strings.HasSuffix(chosen, string(os.PathSeparator))
+}
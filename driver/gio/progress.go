@@ -0,0 +1,61 @@
+// Copyright 2020 Howard C. Shaw III. All rights reserved.
+// Use of this source code is governed by the MIT-license
+// as defined in the LICENSE file.
+
+// +build gio
+
+package gio
+
+import (
+	"image"
+	"image/color"
+
+	rv "github.com/TheGrum/renderview"
+
+	"gioui.org/f32"
+	"gioui.org/layout"
+	"gioui.org/op"
+	"gioui.org/op/paint"
+	"gioui.org/unit"
+	"gioui.org/widget/material"
+)
+
+// ProgressBar shows how far a render has got, as a bar across the
+// bottom of the image labeled with the time left
+type ProgressBar struct {
+	R rv.RenderModel
+}
+
+// Layout draws the bar along the bottom of area, if the render in
+// progress has reported how far it has got
+func (b ProgressBar) Layout(gtx *layout.Context, th *material.Theme, area image.Rectangle) {
+	p := rv.GetProgress(b.R)
+	if !p.Reported() {
+		return
+	}
+	bar := image.Rect(area.Min.X, area.Max.Y-rv.PROGRESS_BAR_HEIGHT, area.Max.X, area.Max.Y).Intersect(area)
+	if bar.Empty() {
+		return
+	}
+	fill := func(rc image.Rectangle, c color.RGBA) {
+		paint.ColorOp{Color: c}.Add(gtx.Ops)
+		paint.PaintOp{Rect: f32.Rectangle{
+			Min: f32.Point{X: float32(rc.Min.X), Y: float32(rc.Min.Y)},
+			Max: f32.Point{X: float32(rc.Max.X), Y: float32(rc.Max.Y)},
+		}}.Add(gtx.Ops)
+	}
+	fill(bar, color.RGBA{0x20, 0x20, 0x20, 0xc0})
+	done := bar
+	done.Max.X = bar.Min.X + int(float64(bar.Dx())*p.Fraction)
+	fill(done, color.RGBA{0x40, 0x80, 0xe0, 0xe0})
+	if th == nil {
+		return
+	}
+	var stack op.StackOp
+	stack.Push(gtx.Ops)
+	op.TransformOp{}.Offset(f32.Point{X: float32(bar.Min.X + 4), Y: float32(bar.Min.Y)}).Add(gtx.Ops)
+	l := th.Label(unit.Px(12), p.String())
+	l.Color = color.RGBA{0xff, 0xff, 0xff, 0xff}
+	l.Layout(gtx)
+	stack.Pop()
+}
@@ -0,0 +1,36 @@
+// Copyright 2020 Howard C. Shaw III. All rights reserved.
+// Use of this source code is governed by the MIT-license
+// as defined in the LICENSE file.
+
+// +build gio
+
+package gio
+
+import (
+	"gioui.org/layout"
+	"gioui.org/widget"
+	"gioui.org/widget/material"
+)
+
+// Section is a collapsible part of the sidebar, headed by a button that
+// shows or hides the rows below it
+type Section struct {
+	Title     string
+	Collapsed bool
+
+	header widget.Button
+}
+
+// LayoutHeader draws the header, toggling the section when it is clicked
+func (s *Section) LayoutHeader(gtx *layout.Context, th *material.Theme) {
+	for s.header.Clicked(gtx) {
+		s.Collapsed = !s.Collapsed
+	}
+	mark := "▾ "
+	if s.Collapsed {
+		mark = "▸ "
+	}
+	b := th.Button(mark + s.Title)
+	b.Background = th.Color.Hint
+	b.Layout(gtx, &s.header)
+}
@@ -0,0 +1,38 @@
+// Copyright 2020 Howard C. Shaw III. All rights reserved.
+// Use of this source code is governed by the MIT-license
+// as defined in the LICENSE file.
+
+// +build gio
+
+package gio
+
+import (
+	rv "github.com/TheGrum/renderview"
+
+	"gioui.org/io/key"
+)
+
+// HandleShortcuts handles the keys that work anywhere in the window,
+// reporting whether e was one of them: Ctrl+S saves the parameters to
+// rv.DefaultParameterFile and Ctrl+O loads them from it, Ctrl+Z undoes
+// the last change and Ctrl+Shift+Z redoes it
+func HandleShortcuts(e key.Event, r rv.RenderModel) bool {
+	if !e.Modifiers.Contain(key.ModCtrl) {
+		return false
+	}
+	switch e.Name {
+	case "S":
+		saveParameterFile(r)
+	case "O":
+		loadParameterFile(r)
+	case "Z":
+		if e.Modifiers.Contain(key.ModShift) {
+			r.GetHistory().Redo()
+		} else {
+			r.GetHistory().Undo()
+		}
+	default:
+		return false
+	}
+	return true
+}
@@ -0,0 +1,137 @@
+// Copyright 2020 Howard C. Shaw III. All rights reserved.
+// Use of this source code is governed by the MIT-license
+// as defined in the LICENSE file.
+
+// +build gio
+
+package gio
+
+import (
+	"image"
+	"image/color"
+	"math"
+
+	"gioui.org/f32"
+	"gioui.org/io/pointer"
+	"gioui.org/layout"
+	"gioui.org/op"
+	"gioui.org/op/paint"
+	"gioui.org/unit"
+)
+
+// Slider is a horizontal track with a draggable thumb for editing a
+// value between Min and Max. Clicking or dragging sets the value, and
+// the scroll wheel moves it by Step.
+type Slider struct {
+	Min, Max, Step float64
+
+	value    float64
+	width    int
+	dragging bool
+	changed  bool
+}
+
+// SetValue moves the thumb without reporting a change
+func (s *Slider) SetValue(v float64) {
+	s.value = s.clamp(v)
+}
+
+// Value returns the current position of the slider
+func (s *Slider) Value() float64 {
+	return s.value
+}
+
+// Changed processes pending pointer events and reports whether the user
+// moved the slider since the last call
+func (s *Slider) Changed(gtx *layout.Context) bool {
+	s.processEvents(gtx)
+	c := s.changed
+	s.changed = false
+	return c
+}
+
+func (s *Slider) clamp(v float64) float64 {
+	if s.Step > 0 {
+		v = s.Min + math.Round((v-s.Min)/s.Step)*s.Step
+	}
+	if v < s.Min {
+		v = s.Min
+	}
+	if v > s.Max {
+		v = s.Max
+	}
+	return v
+}
+
+func (s *Slider) setFromX(x float32) {
+	if s.width <= 0 {
+		return
+	}
+	f := float64(x) / float64(s.width)
+	s.value = s.clamp(s.Min + f*(s.Max-s.Min))
+	s.changed = true
+}
+
+func (s *Slider) processEvents(gtx *layout.Context) {
+	for _, ev := range gtx.Events(s) {
+		e, ok := ev.(pointer.Event)
+		if !ok {
+			continue
+		}
+		switch e.Type {
+		case pointer.Press:
+			if e.Hit {
+				s.dragging = true
+				s.setFromX(e.Position.X)
+			}
+		case pointer.Release, pointer.Cancel:
+			s.dragging = false
+		case pointer.Move:
+			if s.dragging {
+				s.setFromX(e.Position.X)
+			}
+		}
+		if e.Scroll.Y != 0 && e.Hit {
+			step := s.Step
+			if step <= 0 {
+				step = (s.Max - s.Min) / 100
+			}
+			if e.Scroll.Y > 0 {
+				step = -step
+			}
+			s.value = s.clamp(s.value + step)
+			s.changed = true
+		}
+	}
+}
+
+// Layout draws the slider across the available width
+func (s *Slider) Layout(gtx *layout.Context, track color.RGBA, thumb color.RGBA) {
+	s.processEvents(gtx)
+	w := gtx.Constraints.Width.Max
+	h := gtx.Px(unit.Dp(16))
+	s.width = w
+
+	var stack op.StackOp
+	stack.Push(gtx.Ops)
+	paint.ColorOp{Color: track}.Add(gtx.Ops)
+	paint.PaintOp{Rect: f32.Rectangle{
+		Min: f32.Point{X: 0, Y: float32(h)/2 - 2},
+		Max: f32.Point{X: float32(w), Y: float32(h)/2 + 2},
+	}}.Add(gtx.Ops)
+	f := 0.0
+	if s.Max > s.Min {
+		f = (s.value - s.Min) / (s.Max - s.Min)
+	}
+	x := float32(f) * float32(w-h/2)
+	paint.ColorOp{Color: thumb}.Add(gtx.Ops)
+	paint.PaintOp{Rect: f32.Rectangle{
+		Min: f32.Point{X: x, Y: 0},
+		Max: f32.Point{X: x + float32(h)/2, Y: float32(h)},
+	}}.Add(gtx.Ops)
+	pointer.Rect(image.Rectangle{Max: image.Point{X: w, Y: h}}).Add(gtx.Ops)
+	pointer.InputOp{Key: s, Grab: s.dragging}.Add(gtx.Ops)
+	stack.Pop()
+
+	gtx.Dimensions = layout.Dimensions{Size: image.Point{X: w, Y: h}}
+}
@@ -8,8 +8,7 @@ package gio
 
 import (
 	"image"
-
-	//"image/color"
+	"image/color"
 	"image/draw"
 	"log"
 
@@ -22,6 +21,7 @@ import (
 	"gioui.org/io/pointer"
 	"gioui.org/io/system"
 	"gioui.org/layout"
+	"gioui.org/op"
 	"gioui.org/op/paint"
 	"gioui.org/widget/material"
 
@@ -45,42 +45,36 @@ func MainLoop(r rv.RenderModel) {
 	var needsPaint = false
 	var mouseIsDown = false
 	var dragging bool = false
-	var dx, dy float64
 	var sx, sy float32
 	var wx, wy int
 
-	var left, top, right, bottom, width, height, zoom rv.RenderParameter
-	left = r.GetParameter("left")
-	top = r.GetParameter("top")
-	right = r.GetParameter("right")
-	bottom = r.GetParameter("bottom")
-	width = r.GetParameter("width")
-	height = r.GetParameter("height")
-	zoom = r.GetParameter("zoom")
+	width := r.GetParameter("width")
+	height := r.GetParameter("height")
 	mouseX := r.GetParameter("mouseX")
 	mouseY := r.GetParameter("mouseY")
-	options := r.GetParameter("options")
 	page := r.GetParameter("page")
 	//	offsetX := r.GetParameter("offsetX")
 	//	offsetY := r.GetParameter("offsetY")
 	//fmt.Printf("left.GetType() %v", left.GetType())
-	leftIsFloat64 := left.GetType() == "float64"
-	zoomIsFloat64 := zoom.GetType() == "float64"
+	view := rv.NewViewport(r)
 
 	w := app.NewWindow()
 	go func() {
-		//th := material.NewTheme()
+		th := material.NewTheme()
 		gtx := layout.NewContext(w.Queue())
 		for e := range w.Events() {
 			switch e := e.(type) {
 			case system.DestroyEvent:
+				saveSession(r)
 				return
 
 			case system.FrameEvent:
 				wx = e.Size.X
 				wy = e.Size.Y
+				r.Lock()
 				width.SetValueInt(e.Size.X)
 				height.SetValueInt(e.Size.Y)
+				r.Unlock()
 				gtx.Reset(e.Config, e.Size)
 				img := r.Render()
 				if img != nil {
@@ -90,6 +84,8 @@ func MainLoop(r rv.RenderModel) {
 					po.Add(gtx.Ops)
 					//		form(gtx, th)
 				}
+				drawMarkers(gtx, r, image.Rect(0, 0, e.Size.X, e.Size.Y))
+				ProgressBar{R: r}.Layout(gtx, th, image.Rect(0, 0, e.Size.X, e.Size.Y))
 				e.Frame(gtx.Ops)
 
 				//		case paint.Event:
@@ -97,22 +93,33 @@ func MainLoop(r rv.RenderModel) {
 				//			w.Publish()
 
 			case key.Event:
+				if HandleShortcuts(e, r) {
+					needsPaint = true
+				}
 				if e.Name == "⎋ " {
 					//				return nil
 				}
 				if e.Name == "⇞ " {
+					r.GetHistory().Checkpoint("page")
+					r.Lock()
 					page.SetValueInt(page.GetValueInt() - 1)
+					r.Unlock()
 					needsPaint = true
 				}
 				if e.Name == "⇟ " {
+					r.GetHistory().Checkpoint("page")
+					r.Lock()
 					page.SetValueInt(page.GetValueInt() + 1)
+					r.Unlock()
 					needsPaint = true
 				}
 
 			case pointer.Event:
 				//fmt.Printf("mouse pos(%v)\n", e)
+				r.Lock()
 				mouseX.SetValueFloat64(float64(e.Position.X))
 				mouseY.SetValueFloat64(float64(e.Position.Y))
+				r.Unlock()
 
 				if mouseIsDown == false && dragging == false && (e.Buttons&pointer.ButtonLeft) == pointer.ButtonLeft {
 					//fmt.Printf("mouse down left(%v)\n", e)
@@ -121,62 +128,18 @@ func MainLoop(r rv.RenderModel) {
 					mouseIsDown = true
 				}
 				if e.Scroll.Y > 0 {
-					if zoomIsFloat64 {
-						zoom.SetValueFloat64(zoom.GetValueFloat64() - 1)
-					} else {
-						zoom.SetValueInt(zoom.GetValueInt() - 1)
-					}
-					if options.GetValueInt()&rv.OPT_AUTO_ZOOM == rv.OPT_AUTO_ZOOM || options.GetValueInt()&rv.OPT_CENTER_ZOOM == rv.OPT_CENTER_ZOOM {
-						mult := 1 + rv.ZOOM_RATE
-						if leftIsFloat64 {
-
-							zwidth := right.GetValueFloat64() - left.GetValueFloat64()
-							zheight := bottom.GetValueFloat64() - top.GetValueFloat64()
-							nzwidth := zwidth * mult
-							nzheight := zheight * mult
-							cx := float64(e.Position.X) / float64(wx)
-							cy := float64(e.Position.Y) / float64(wy)
-							if options.GetValueInt()&rv.OPT_CENTER_ZOOM == rv.OPT_CENTER_ZOOM {
-								cx = 0.5
-								cy = 0.5
-							}
-							//fmt.Printf("zoomOut: mult: %v zwidth: %v nzwidth: %v cx: %v left: %v nleft: %v\n", mult, zwidth, nzwidth, cx, left.GetValueFloat64(), left.GetValueFloat64()-((nzwidth-zwidth)*cx))
-							left.SetValueFloat64(left.GetValueFloat64() - ((nzwidth - zwidth) * cx))
-							top.SetValueFloat64(top.GetValueFloat64() - ((nzheight - zheight) * cy))
-							right.SetValueFloat64(left.GetValueFloat64() + nzwidth)
-							bottom.SetValueFloat64(top.GetValueFloat64() + nzheight)
-							needsPaint = true
-
-						}
-					}
-
+					r.GetHistory().Checkpoint("zoom")
+					r.Lock()
+					view.ZoomAt(float64(e.Position.X), float64(e.Position.Y), true)
+					needsPaint = true
+					r.Unlock()
 				}
 				if e.Scroll.Y < 0 {
-					if zoomIsFloat64 {
-						zoom.SetValueFloat64(zoom.GetValueFloat64() + 1)
-					} else {
-						zoom.SetValueInt(zoom.GetValueInt() + 1)
-					}
-					if options.GetValueInt()&rv.OPT_AUTO_ZOOM == rv.OPT_AUTO_ZOOM || options.GetValueInt()&rv.OPT_CENTER_ZOOM == rv.OPT_CENTER_ZOOM {
-						mult := 1 - rv.ZOOM_RATE
-						if leftIsFloat64 {
-							zwidth := right.GetValueFloat64() - left.GetValueFloat64()
-							zheight := bottom.GetValueFloat64() - top.GetValueFloat64()
-							nzwidth := zwidth * mult
-							nzheight := zheight * mult
-							cx := float64(e.Position.X) / float64(wx)
-							cy := float64(e.Position.Y) / float64(wy)
-							if options.GetValueInt()&rv.OPT_CENTER_ZOOM == rv.OPT_CENTER_ZOOM {
-								cx = 0.5
-								cy = 0.5
-							}
-							left.SetValueFloat64(left.GetValueFloat64() - ((nzwidth - zwidth) * cx))
-							top.SetValueFloat64(top.GetValueFloat64() - ((nzheight - zheight) * cy))
-							right.SetValueFloat64(left.GetValueFloat64() + nzwidth)
-							bottom.SetValueFloat64(top.GetValueFloat64() + nzheight)
-							needsPaint = true
-						}
-					}
+					r.GetHistory().Checkpoint("zoom")
+					r.Lock()
+					view.ZoomAt(float64(e.Position.X), float64(e.Position.Y), false)
+					needsPaint = true
+					r.Unlock()
 				}
 				if mouseIsDown {
 					//				fmt.Printf("mouse drag(%v) dragging (%v)\n", e, dragging)
@@ -187,33 +150,10 @@ func MainLoop(r rv.RenderModel) {
 							//					fmt.Printf("Dragging.\n")
 						}
 					} else {
-						if leftIsFloat64 {
-							width := right.GetValueFloat64() - left.GetValueFloat64()
-							height := bottom.GetValueFloat64() - top.GetValueFloat64()
-							dx = width / float64(wx)
-							dy = height / float64(wy)
-							cx := float64(e.Position.X-sx) * dx
-							cy := float64(e.Position.Y-sy) * dy
-							//fmt.Printf("dx %v dy %v cx %v cy %v\n", dx, dy, cx, cy)
-							left.SetValueFloat64(left.GetValueFloat64() - cx)
-							right.SetValueFloat64(right.GetValueFloat64() - cx)
-							top.SetValueFloat64(top.GetValueFloat64() - cy)
-							bottom.SetValueFloat64(bottom.GetValueFloat64() - cy)
-							//							fmt.Printf("left %v right %v top %v bottom %v", left.GetValueFloat64(), right.GetValueFloat64(), top.GetValueFloat64(), bottom.GetValueFloat64())
-						} else {
-							width := right.GetValueInt() - left.GetValueInt()
-							height := bottom.GetValueInt() - top.GetValueInt()
-							dx = float64(width) / float64(wx)
-							dy = float64(height) / float64(wy)
-							cx := float64(e.Position.X-sx) * dx
-							cy := float64(e.Position.Y-sy) * dy
-							//fmt.Printf("dx %v dy %v cx %v cy %v\n", dx, dy, cx, cy)
-							left.SetValueInt(int(float64(left.GetValueInt()) - cx))
-							right.SetValueInt(int(float64(right.GetValueInt()) - cx))
-							top.SetValueInt(int(float64(top.GetValueInt()) - cy))
-							bottom.SetValueInt(int(float64(bottom.GetValueInt()) - cy))
-							//							fmt.Printf("left %v right %v top %v bottom %v", left.GetValueInt(), right.GetValueInt(), top.GetValueInt(), bottom.GetValueInt())
-						}
+						r.GetHistory().Checkpoint("pan")
+						r.Lock()
+						view.Pan(float64(e.Position.X-sx), float64(e.Position.Y-sy))
+						r.Unlock()
 						ni := paint.NewImageOp(r.Render())
 						ni.Add(gtx.Ops)
 						po := paint.PaintOp{f32.Rectangle{f32.Point{0, 0}, f32.Point{float32(wx), float32(wy)}}}
@@ -227,6 +167,10 @@ func MainLoop(r rv.RenderModel) {
 					}
 				}
 				if e.Buttons == 0 {
+					// a click, rather than the end of a drag, picks a point
+					if mouseIsDown && !dragging && rv.PickAt(r, float64(e.Position.X), float64(e.Position.Y)) {
+						needsPaint = true
+					}
 					dragging = false
 					mouseIsDown = false
 				}
@@ -264,9 +208,37 @@ func MainLoop(r rv.RenderModel) {
 		w.Invalidate()
 		//		w.Send(paint.Event{})
 	})
+	// restoring may request a paint, so it waits for the paint function
+	restoreSession(r)
 	app.Main()
 }
 
+// drawMarkers paints a crosshair at each point picked with HINT_PICK,
+// clipped to area, the part of the window showing the image
+func drawMarkers(gtx *layout.Context, r rv.RenderModel, area image.Rectangle) {
+	r.Lock()
+	markers := rv.PickMarkers(r)
+	r.Unlock()
+	fill := func(rects []image.Rectangle, c color.RGBA) {
+		paint.ColorOp{Color: c}.Add(gtx.Ops)
+		for _, rc := range rects {
+			rc = rc.Intersect(area)
+			if rc.Empty() {
+				continue
+			}
+			paint.PaintOp{Rect: f32.Rectangle{
+				Min: f32.Point{X: float32(rc.Min.X), Y: float32(rc.Min.Y)},
+				Max: f32.Point{X: float32(rc.Max.X), Y: float32(rc.Max.Y)},
+			}}.Add(gtx.Ops)
+		}
+	}
+	for _, pt := range markers {
+		outlines, arms := rv.CrosshairRects(pt)
+		fill(outlines, color.RGBA{0, 0, 0, 0xff})
+		fill(arms, color.RGBA{0xff, 0xff, 0xff, 0xff})
+	}
+}
+
 func Draw(mimg image.Image, bimg *image.RGBA) {
 	if !(mimg == nil) && !(bimg == nil) {
 		r := mimg.Bounds()
@@ -292,32 +264,21 @@ const SIDEBAR_WIDTH = 160
 
 func MainLoopWithWidgets(r rv.RenderModel) {
 	var needsPaint = true
-	var editorsChanged = true
 	var mouseIsDown = false
 	var dragging bool = false
-	var dx, dy float64
 	var sx, sy float32
-	var wx, wy int
 
-	var left, top, right, bottom, width, height, zoom rv.RenderParameter
-	left = r.GetParameter("left")
-	top = r.GetParameter("top")
-	right = r.GetParameter("right")
-	bottom = r.GetParameter("bottom")
-	width = r.GetParameter("width")
-	height = r.GetParameter("height")
-	zoom = r.GetParameter("zoom")
+	width := r.GetParameter("width")
+	height := r.GetParameter("height")
 	mouseX := r.GetParameter("mouseX")
 	mouseY := r.GetParameter("mouseY")
-	options := r.GetParameter("options")
 	page := r.GetParameter("page")
 	sidebarWidth := r.GetParameter("sidebarWidth")
 	//	offsetX := r.GetParameter("offsetX")
 	//	offsetY := r.GetParameter("offsetY")
 	//fmt.Printf("left.GetType() %v", left.GetType())
-	leftIsFloat64 := left.GetType() == "float64"
-	zoomIsFloat64 := zoom.GetType() == "float64"
-	paramEditors := []ParamEdit{}
+	view := rv.NewViewport(r)
+	paramEditors := []*ParamEdit{}
 	var fullTextEditor ParamEdit
 	paramList := &layout.List{
 		Axis: layout.Vertical,
@@ -331,44 +292,60 @@ func MainLoopWithWidgets(r rv.RenderModel) {
 		param := r.GetParameter(pnames[0])
 		fullTextEditor = ParamEdit{
 			P: param,
+			R: r,
 			N: new(widget.Editor),
+			H: r.GetHistory(),
 		}
 		fullTextEditor.N.SetText(rv.GetParameterValueAsString(fullTextEditor.P))
 	}
-	for _, pname := range r.GetHintedParameterNamesWithFallback(rv.HINT_SIDEBAR | rv.HINT_FOOTER) {
-		param := r.GetParameter(pname)
-		paramEdit := ParamEdit{
-			P: param,
-			N: &widget.Editor{
-				SingleLine: true,
-			}}
-		paramEditors = append(paramEditors, paramEdit)
-		paramEdit.N.SetText(rv.GetParameterValueAsString(paramEdit.P))
+	sections := []*paramSection{}
+	for _, g := range r.GetParameterGroups(rv.HINT_SIDEBAR) {
+		sec := &paramSection{Section: Section{Title: g.Title()}}
+		for _, pname := range g.Params {
+			sec.Editors = append(sec.Editors, newParamEdit(r, r.GetParameter(pname)))
+		}
+		paramEditors = append(paramEditors, sec.Editors...)
+		sections = append(sections, sec)
+	}
+	footerEditors := []*ParamEdit{}
+	for _, pname := range r.GetHintedParameterNames(rv.HINT_FOOTER) {
+		footerEditors = append(footerEditors, newParamEdit(r, r.GetParameter(pname)))
 	}
+	paramEditors = append(paramEditors, footerEditors...)
+	bookmarks := NewBookmarkPanel(r)
+	animation := NewAnimationPanel(r)
+	hover := new(Hover)
 
 	w := app.NewWindow()
+	if fullTextEditor.N != nil {
+		fullTextEditor.Watch(w)
+	}
+	for _, pe := range paramEditors {
+		pe.Watch(w)
+	}
 	go func() {
 		th := material.NewTheme()
 		gtx := layout.NewContext(w.Queue())
 		for e := range w.Events() {
-			if editorsChanged == true {
-				// may also need parameters updated
-				if fullTextEditor.N != nil {
-					fullTextEditor.N.SetText(rv.GetParameterValueAsString(fullTextEditor.P))
-				}
-				for _, pe := range paramEditors {
-					pe.N.SetText(rv.GetParameterValueAsString(pe.P))
-				}
-				editorsChanged = false
+			if fullTextEditor.N != nil {
+				fullTextEditor.refreshIfDirty()
+			}
+			if bookmarks.B.Step() {
+				needsPaint = true
+			}
+			if animation.T.Step() {
+				needsPaint = true
+			}
+			for _, pe := range paramEditors {
+				pe.refreshIfDirty()
 			}
 			switch e := e.(type) {
 			case system.DestroyEvent:
+				saveSession(r)
 				return
 
 			case system.FrameEvent:
-				wx = e.Size.X
-				wy = e.Size.Y
-				if len(paramEditors) > 0 {
+				if len(sections) > 0 {
 					sbw = sidebarWidth.GetValueInt()
 					if sbw == 0 {
 						sbw = SIDEBAR_WIDTH
@@ -383,30 +360,34 @@ func MainLoopWithWidgets(r rv.RenderModel) {
 					}
 				}
 				//	fmt.Printf("lx: %v", lx)
+				r.Lock()
 				width.SetValueInt(e.Size.X)
-				height.SetValueInt(e.Size.Y)
+				r.Unlock()
 				gtx.Reset(e.Config, e.Size)
+				hover.Update(gtx)
 				gtx.Constraints.Width.Max = sbw
 				widgetList := []func(){}
-				widgetList = append(widgetList, func() {
-					th.Label(unit.Dp(15), "____Parameters____").Layout(gtx)
-				})
-				for _, pe := range paramEditors {
-					//fmt.Printf("pe: %v %v %v\n", pe, pe.P.GetName(), pe.N.Text())
-					widgetList = append(widgetList, func(pe ParamEdit) func() {
+				for _, sec := range sections {
+					widgetList = append(widgetList, func(sec *paramSection) func() {
 						return func() {
-							th.Label(unit.Dp(15), pe.P.GetName()).Layout(gtx)
+							sec.LayoutHeader(gtx, th)
 						}
-					}(pe))
-					widgetList = append(widgetList, func(pe ParamEdit) func() {
-						return func() {
-							th.Editor(pe.P.GetName()).Layout(gtx, pe.N)
-							for range pe.N.Events(gtx) {
-								rv.SetParameterValueFromString(pe.P, pe.N.Text())
+					}(sec))
+					if sec.Collapsed {
+						continue
+					}
+					for _, pe := range sec.Editors {
+						widgetList = append(widgetList, func(pe *ParamEdit) func() {
+							return func() {
+								if pe.Layout(gtx, th, hover) {
+									needsPaint = true
+								}
 							}
-						}
-					}(pe))
+						}(pe))
+					}
 				}
+				widgetList = append(widgetList, bookmarks.Widgets(gtx, th, w.Invalidate)...)
+				widgetList = append(widgetList, animation.Widgets(gtx, th, w.Invalidate)...)
 				paramList.Layout(gtx, len(widgetList), func(i int) {
 					layout.UniformInset(unit.Dp(1)).Layout(gtx, widgetList[i])
 				})
@@ -414,52 +395,92 @@ func MainLoopWithWidgets(r rv.RenderModel) {
 				if fullTextEditor.N != nil {
 					gtx.Constraints.Width.Max = sbw + (SIDEBAR_WIDTH * 2) - 5
 					layout.Inset{Top: unit.Dp(2), Left: unit.Dp(float32(sbw + 5))}.Layout(gtx, func() {
-						layout.Stack{Alignment: layout.N}.Layout(gtx,
-							//							layout.Stacked(func() {
+						layout.Flex{Axis: layout.Vertical}.Layout(gtx,
+							//							layout.Rigid(func() {
 							//								th.Label(unit.Dp(15), fullTextEditor.P.GetName()).Layout(gtx)
 							//							}),
-							layout.Expanded(func() {
-								th.Editor(fullTextEditor.P.GetName()).Layout(gtx, fullTextEditor.N)
+							layout.Flexed(1, func() {
+								fullTextEditor.LayoutEditor(gtx, th)
 								//fullTextEditor.N.SetText(fullTextEditor.P.GetValueString())
-								for range fullTextEditor.N.Events(gtx) {
-									rv.SetParameterValueFromString(fullTextEditor.P, fullTextEditor.N.Text())
-								}
+							}),
+							layout.Rigid(func() {
+								fullTextEditor.LayoutError(gtx, th)
 							}))
 					})
 				}
+				// the footer is recorded first to learn how much height it leaves the image
+				var footer op.MacroOp
+				fh := 0
+				if len(footerEditors) > 0 {
+					footer.Record(gtx.Ops)
+					gtx.Constraints = layout.RigidConstraints(image.Point{X: e.Size.X - lx, Y: e.Size.Y})
+					gtx.Constraints.Width.Min = 0
+					gtx.Constraints.Height.Min = 0
+					if LayoutFooter(gtx, th, hover, footerEditors) {
+						needsPaint = true
+					}
+					footer.Stop()
+					fh = gtx.Dimensions.Size.Y
+				}
+				r.Lock()
+				height.SetValueInt(e.Size.Y - fh)
+				r.Unlock()
 				//gtx.Reset(e.Config, e.Size)
 				gtx.Constraints.Width.Max = e.Size.X
 				img := r.Render()
 				if img != nil {
 					ni := paint.NewImageOp(img)
 					ni.Add(gtx.Ops)
-					po := paint.PaintOp{f32.Rectangle{f32.Point{float32(lx), 0}, f32.Point{float32(img.Bounds().Size().X), float32(img.Bounds().Size().Y)}}}
+					ih := img.Bounds().Size().Y
+					if ih > e.Size.Y-fh {
+						ih = e.Size.Y - fh
+					}
+					po := paint.PaintOp{f32.Rectangle{f32.Point{float32(lx), 0}, f32.Point{float32(img.Bounds().Size().X), float32(ih)}}}
 					po.Add(gtx.Ops)
 				}
+				drawMarkers(gtx, r, image.Rect(lx, 0, e.Size.X, e.Size.Y-fh))
+				ProgressBar{R: r}.Layout(gtx, th, image.Rect(lx, 0, e.Size.X, e.Size.Y-fh))
+				if fh > 0 {
+					var stack op.StackOp
+					stack.Push(gtx.Ops)
+					op.TransformOp{}.Offset(f32.Point{X: float32(lx), Y: float32(e.Size.Y - fh)}).Add(gtx.Ops)
+					footer.Add()
+					stack.Pop()
+				}
+				hover.Layout(gtx, e.Size)
 
 				needsPaint = false
 
 				e.Frame(gtx.Ops)
 
 			case key.Event:
+				if HandleShortcuts(e, r) {
+					needsPaint = true
+				}
 				if e.Name == "⎋ " {
 					//				return nil
 				}
 				if e.Name == "⇞ " {
+					r.GetHistory().Checkpoint("page")
+					r.Lock()
 					page.SetValueInt(page.GetValueInt() - 1)
-					editorsChanged = true
+					r.Unlock()
 					needsPaint = true
 				}
 				if e.Name == "⇟ " {
+					r.GetHistory().Checkpoint("page")
+					r.Lock()
 					page.SetValueInt(page.GetValueInt() + 1)
-					editorsChanged = true
+					r.Unlock()
 					needsPaint = true
 				}
 
 			case pointer.Event:
 				//fmt.Printf("mouse pos(%v)\n", e)
+				r.Lock()
 				mouseX.SetValueFloat64(float64(e.Position.X))
 				mouseY.SetValueFloat64(float64(e.Position.Y))
+				r.Unlock()
 
 				if e.Position.X <= float32(lx) {
 					break
@@ -471,62 +492,18 @@ func MainLoopWithWidgets(r rv.RenderModel) {
 					mouseIsDown = true
 				}
 				if e.Scroll.Y > 0 {
-					if zoomIsFloat64 {
-						zoom.SetValueFloat64(zoom.GetValueFloat64() - 1)
-					} else {
-						zoom.SetValueInt(zoom.GetValueInt() - 1)
-					}
-					if options.GetValueInt()&rv.OPT_AUTO_ZOOM == rv.OPT_AUTO_ZOOM || options.GetValueInt()&rv.OPT_CENTER_ZOOM == rv.OPT_CENTER_ZOOM {
-						mult := 1 + rv.ZOOM_RATE
-						if leftIsFloat64 {
-
-							zwidth := right.GetValueFloat64() - left.GetValueFloat64()
-							zheight := bottom.GetValueFloat64() - top.GetValueFloat64()
-							nzwidth := zwidth * mult
-							nzheight := zheight * mult
-							cx := float64(e.Position.X) / float64(wx)
-							cy := float64(e.Position.Y) / float64(wy)
-							if options.GetValueInt()&rv.OPT_CENTER_ZOOM == rv.OPT_CENTER_ZOOM {
-								cx = 0.5
-								cy = 0.5
-							}
-							//fmt.Printf("zoomOut: mult: %v zwidth: %v nzwidth: %v cx: %v left: %v nleft: %v\n", mult, zwidth, nzwidth, cx, left.GetValueFloat64(), left.GetValueFloat64()-((nzwidth-zwidth)*cx))
-							left.SetValueFloat64(left.GetValueFloat64() - ((nzwidth - zwidth) * cx))
-							top.SetValueFloat64(top.GetValueFloat64() - ((nzheight - zheight) * cy))
-							right.SetValueFloat64(left.GetValueFloat64() + nzwidth)
-							bottom.SetValueFloat64(top.GetValueFloat64() + nzheight)
-							needsPaint = true
-						}
-					}
-					editorsChanged = true
+					r.GetHistory().Checkpoint("zoom")
+					r.Lock()
+					view.ZoomAt(float64(e.Position.X), float64(e.Position.Y), true)
+					needsPaint = true
+					r.Unlock()
 				}
 				if e.Scroll.Y < 0 {
-					if zoomIsFloat64 {
-						zoom.SetValueFloat64(zoom.GetValueFloat64() + 1)
-					} else {
-						zoom.SetValueInt(zoom.GetValueInt() + 1)
-					}
-					if options.GetValueInt()&rv.OPT_AUTO_ZOOM == rv.OPT_AUTO_ZOOM || options.GetValueInt()&rv.OPT_CENTER_ZOOM == rv.OPT_CENTER_ZOOM {
-						mult := 1 - rv.ZOOM_RATE
-						if leftIsFloat64 {
-							zwidth := right.GetValueFloat64() - left.GetValueFloat64()
-							zheight := bottom.GetValueFloat64() - top.GetValueFloat64()
-							nzwidth := zwidth * mult
-							nzheight := zheight * mult
-							cx := float64(e.Position.X) / float64(wx)
-							cy := float64(e.Position.Y) / float64(wy)
-							if options.GetValueInt()&rv.OPT_CENTER_ZOOM == rv.OPT_CENTER_ZOOM {
-								cx = 0.5
-								cy = 0.5
-							}
-							left.SetValueFloat64(left.GetValueFloat64() - ((nzwidth - zwidth) * cx))
-							top.SetValueFloat64(top.GetValueFloat64() - ((nzheight - zheight) * cy))
-							right.SetValueFloat64(left.GetValueFloat64() + nzwidth)
-							bottom.SetValueFloat64(top.GetValueFloat64() + nzheight)
-							needsPaint = true
-						}
-					}
-					editorsChanged = true
+					r.GetHistory().Checkpoint("zoom")
+					r.Lock()
+					view.ZoomAt(float64(e.Position.X), float64(e.Position.Y), false)
+					needsPaint = true
+					r.Unlock()
 				}
 				if mouseIsDown {
 					//				fmt.Printf("mouse drag(%v) dragging (%v)\n", e, dragging)
@@ -537,34 +514,10 @@ func MainLoopWithWidgets(r rv.RenderModel) {
 							//					fmt.Printf("Dragging.\n")
 						}
 					} else {
-						if leftIsFloat64 {
-							width := right.GetValueFloat64() - left.GetValueFloat64()
-							height := bottom.GetValueFloat64() - top.GetValueFloat64()
-							dx = width / float64(wx)
-							dy = height / float64(wy)
-							cx := float64(e.Position.X-sx) * dx
-							cy := float64(e.Position.Y-sy) * dy
-							//fmt.Printf("dx %v dy %v cx %v cy %v\n", dx, dy, cx, cy)
-							left.SetValueFloat64(left.GetValueFloat64() - cx)
-							right.SetValueFloat64(right.GetValueFloat64() - cx)
-							top.SetValueFloat64(top.GetValueFloat64() - cy)
-							bottom.SetValueFloat64(bottom.GetValueFloat64() - cy)
-							//							fmt.Printf("left %v right %v top %v bottom %v", left.GetValueFloat64(), right.GetValueFloat64(), top.GetValueFloat64(), bottom.GetValueFloat64())
-						} else {
-							width := right.GetValueInt() - left.GetValueInt()
-							height := bottom.GetValueInt() - top.GetValueInt()
-							dx = float64(width) / float64(wx)
-							dy = float64(height) / float64(wy)
-							cx := float64(e.Position.X-sx) * dx
-							cy := float64(e.Position.Y-sy) * dy
-							//fmt.Printf("dx %v dy %v cx %v cy %v\n", dx, dy, cx, cy)
-							left.SetValueInt(int(float64(left.GetValueInt()) - cx))
-							right.SetValueInt(int(float64(right.GetValueInt()) - cx))
-							top.SetValueInt(int(float64(top.GetValueInt()) - cy))
-							bottom.SetValueInt(int(float64(bottom.GetValueInt()) - cy))
-							//							fmt.Printf("left %v right %v top %v bottom %v", left.GetValueInt(), right.GetValueInt(), top.GetValueInt(), bottom.GetValueInt())
-						}
-						editorsChanged = true
+						r.GetHistory().Checkpoint("pan")
+						r.Lock()
+						view.Pan(float64(e.Position.X-sx), float64(e.Position.Y-sy))
+						r.Unlock()
 						needsPaint = true
 						// ni := paint.NewImageOp(r.Render())
 						// ni.Add(gtx.Ops)
@@ -579,6 +532,10 @@ func MainLoopWithWidgets(r rv.RenderModel) {
 					}
 				}
 				if e.Buttons == 0 {
+					// a click, rather than the end of a drag, picks a point
+					if mouseIsDown && !dragging && rv.PickAt(r, float64(e.Position.X), float64(e.Position.Y)) {
+						needsPaint = true
+					}
 					dragging = false
 					mouseIsDown = false
 				}
@@ -597,6 +554,9 @@ func MainLoopWithWidgets(r rv.RenderModel) {
 			default:
 
 			}
+			if needsPaint {
+				w.Invalidate()
+			}
 		}
 	}()
 
@@ -608,10 +568,386 @@ func MainLoopWithWidgets(r rv.RenderModel) {
 		w.Invalidate()
 		//		w.Send(paint.Event{})
 	})
+	// restoring may request a paint, so it waits for the paint function
+	restoreSession(r)
 	app.Main()
 }
 
+// ParamEdit pairs a parameter with the widget editing it; N for
+// text editing, C for bool parameters, E for choice parameters, S
+// for bounded numeric parameters, or K, one Slider per channel, for
+// color parameters. Path parameters use N with the completions in PC,
+// and list parameters L.
+// Read-only parameters have no widget; their value is drawn as a caption.
 type ParamEdit struct {
-	P rv.RenderParameter
-	N *widget.Editor
+	P  rv.RenderParameter
+	N  *widget.Editor
+	PC *PathCompletion
+	L  *ListEdit
+	C  *widget.CheckBox
+	E  *widget.Enum
+	S  *Slider
+	K  []*Slider
+
+	// R is locked while the widget writes the parameter
+	R rv.RenderModel
+	// H records edits for undo
+	H *rv.History
+
+	// hover tracks the pointer over the label, to show the description
+	hover HoverArea
+
+	// Err holds the reason the text in N was rejected, if it was
+	Err error
+
+	// dirty is set when the parameter changes behind the widget's back,
+	// editing while the widget itself is writing the parameter. Both are
+	// guarded by the model lock, which is held whenever a parameter changes.
+	dirty   bool
+	editing bool
+}
+
+// newParamEdit creates the widget suited to the parameter's type
+func newParamEdit(r rv.RenderModel, param rv.RenderParameter) *ParamEdit {
+	paramEdit := &ParamEdit{
+		P: param,
+		R: r,
+		H: r.GetHistory(),
+	}
+	if rv.IsReadOnly(param) {
+		return paramEdit
+	}
+	switch param.GetType() {
+	case "bool":
+		paramEdit.C = new(widget.CheckBox)
+	case "choice":
+		paramEdit.E = new(widget.Enum)
+	case "path":
+		paramEdit.N = &widget.Editor{
+			SingleLine: true,
+			Submit:     true,
+		}
+		paramEdit.PC = new(PathCompletion)
+	case "[]int", "[]float64", "[]string":
+		paramEdit.L = new(ListEdit)
+	case "color":
+		paramEdit.K = make([]*Slider, 4)
+		for i := range paramEdit.K {
+			paramEdit.K[i] = &Slider{Min: 0, Max: 255, Step: 1}
+		}
+	case "int", "float64":
+		if min, max, step, bounded := param.GetRange(); bounded {
+			paramEdit.S = &Slider{Min: min, Max: max, Step: step}
+			break
+		}
+		fallthrough
+	default:
+		paramEdit.N = &widget.Editor{
+			SingleLine: true,
+		}
+	}
+	paramEdit.Refresh()
+	return paramEdit
+}
+
+// paramSection is a parameter group in the sidebar
+type paramSection struct {
+	Section
+	Editors []*ParamEdit
+}
+
+// Watch subscribes the editor to changes of its parameter, marking it
+// for Refresh and waking the window
+func (pe *ParamEdit) Watch(w *app.Window) {
+	pe.P.OnChange(func(oldValue interface{}, newValue interface{}) {
+		if !pe.editing {
+			pe.dirty = true
+			w.Invalidate()
+		}
+	})
+}
+
+// set runs f, which writes the parameter from the widget, under the
+// model lock and without marking the widget for refresh, recording the
+// change in the history
+func (pe *ParamEdit) set(f func()) {
+	if pe.H != nil {
+		pe.H.Checkpoint(pe.P.GetName())
+	}
+	if pe.R != nil {
+		pe.R.Lock()
+		defer pe.R.Unlock()
+	}
+	pe.editing = true
+	f()
+	pe.editing = false
+}
+
+// channelColors tint the thumbs of the red, green, blue and alpha sliders
+var channelColors = []color.RGBA{
+	{0xd0, 0x20, 0x20, 0xff},
+	{0x20, 0xa0, 0x20, 0xff},
+	{0x20, 0x40, 0xd0, 0xff},
+	{0x60, 0x60, 0x60, 0xff},
+}
+
+// Swatch fills a strip across the available width with c
+func Swatch(gtx *layout.Context, c color.RGBA) {
+	w := gtx.Constraints.Width.Max
+	h := gtx.Px(unit.Dp(20))
+	paint.ColorOp{Color: c}.Add(gtx.Ops)
+	paint.PaintOp{Rect: f32.Rectangle{Max: f32.Point{X: float32(w), Y: float32(h)}}}.Add(gtx.Ops)
+	gtx.Dimensions = layout.Dimensions{Size: image.Point{X: w, Y: h}}
+}
+
+// errorColor marks editors holding input that was rejected
+var errorColor = color.RGBA{0xc0, 0x00, 0x00, 0xff}
+
+// LayoutEditor draws the text editor, in errorColor while its contents
+// are rejected, and stores the text in the parameter as it is edited.
+// The parameter keeps its last good value until the text is fixed.
+func (pe *ParamEdit) LayoutEditor(gtx *layout.Context, th *material.Theme) {
+	ed := th.Editor(rv.GetParameterLabel(pe.P))
+	if pe.Err != nil {
+		ed.Color = errorColor
+	}
+	if u := pe.P.GetMeta(rv.META_UNIT); u != "" {
+		layout.Flex{}.Layout(gtx,
+			layout.Flexed(1, func() {
+				ed.Layout(gtx, pe.N)
+			}),
+			layout.Rigid(func() {
+				layout.Inset{Left: unit.Dp(4)}.Layout(gtx, func() {
+					th.Caption(u).Layout(gtx)
+				})
+			}))
+	} else {
+		ed.Layout(gtx, pe.N)
+	}
+	for _, e := range pe.N.Events(gtx) {
+		if pe.PC != nil {
+			// a path is only stored once it is submitted, so partly
+			// typed paths are offered completions instead
+			pe.PC.Update(pe.N.Text(), pe.P)
+			if _, ok := e.(widget.SubmitEvent); !ok {
+				continue
+			}
+		}
+		pe.store()
+	}
+}
+
+// store sets the parameter from the editor's text
+func (pe *ParamEdit) store() {
+	if pe.N.Text() == rv.FormatParameterValue(pe.P) {
+		pe.Err = nil
+		return
+	}
+	pe.set(func() { pe.Err = rv.SetParameterValueFromString(pe.P, pe.N.Text()) })
+}
+
+// Layout draws the parameter's label and editor stacked, reporting
+// whether the user changed the parameter
+func (pe *ParamEdit) Layout(gtx *layout.Context, th *material.Theme, h *Hover) bool {
+	changed := false
+	label := rv.GetParameterLabel(pe.P)
+	if rv.IsReadOnly(pe.P) {
+		// read on every frame, so the value follows the renderer
+		layout.Flex{Axis: layout.Vertical}.Layout(gtx,
+			layout.Rigid(func() {
+				pe.LayoutHover(gtx, th, h, func() {
+					th.Label(unit.Dp(15), label).Layout(gtx)
+				})
+			}),
+			layout.Rigid(func() {
+				th.Caption(pe.FormatValue()).Layout(gtx)
+			}))
+		return false
+	}
+	if pe.C != nil {
+		// checkboxes carry their own label
+		pe.LayoutHover(gtx, th, h, func() {
+			th.CheckBox(label).Layout(gtx, pe.C)
+		})
+		if v := pe.C.Checked(gtx); v != pe.P.GetValueBool() {
+			pe.set(func() { pe.P.SetValueBool(v) })
+			changed = true
+		}
+		return changed
+	}
+	var rows []layout.FlexChild
+	rows = append(rows, layout.Rigid(func() {
+		pe.LayoutHover(gtx, th, h, func() {
+			th.Label(unit.Dp(15), label).Layout(gtx)
+		})
+	}))
+	switch {
+	case pe.E != nil:
+		for _, c := range pe.P.GetChoices() {
+			c := c
+			rows = append(rows, layout.Rigid(func() {
+				th.RadioButton(c, c).Layout(gtx, pe.E)
+				if v := pe.E.Value(gtx); v != pe.P.GetValueString() {
+					pe.set(func() { pe.P.SetValueString(v) })
+					changed = true
+				}
+			}))
+		}
+	case pe.K != nil:
+		rows = append(rows, layout.Rigid(func() {
+			Swatch(gtx, pe.P.GetValueColor())
+		}))
+		for i := range pe.K {
+			i := i
+			rows = append(rows, layout.Rigid(func() {
+				pe.K[i].Layout(gtx, th.Color.Hint, channelColors[i])
+				if pe.K[i].Changed(gtx) {
+					pe.set(func() {
+						pe.P.SetValueColor(color.RGBA{
+							uint8(pe.K[0].Value()),
+							uint8(pe.K[1].Value()),
+							uint8(pe.K[2].Value()),
+							uint8(pe.K[3].Value())})
+					})
+					changed = true
+				}
+			}))
+		}
+		rows = append(rows, layout.Rigid(func() {
+			th.Caption(pe.FormatValue()).Layout(gtx)
+		}))
+	case pe.L != nil:
+		rows = append(rows, layout.Rigid(func() {
+			items, edited := pe.L.Layout(gtx, th, pe.P)
+			if !edited || rv.FormatList(items) == rv.FormatList(rv.GetListItems(pe.P)) {
+				return
+			}
+			pe.set(func() { pe.Err = rv.SetListItems(pe.P, items) })
+			if pe.Err != nil {
+				// keep the rejected text in the editors to be fixed
+				pe.L.SetItems(items)
+				return
+			}
+			pe.L.SetItems(rv.GetListItems(pe.P))
+			changed = true
+		}))
+		rows = append(rows, layout.Rigid(func() {
+			pe.LayoutError(gtx, th)
+		}))
+	case pe.S != nil:
+		rows = append(rows, layout.Rigid(func() {
+			pe.S.Layout(gtx, th.Color.Hint, th.Color.Primary)
+			if pe.S.Changed(gtx) {
+				pe.set(func() { pe.S.SetValue(rv.SetParameterValueFromFloat64(pe.P, pe.S.Value())) })
+				changed = true
+			}
+		}))
+		rows = append(rows, layout.Rigid(func() {
+			th.Caption(pe.FormatValue()).Layout(gtx)
+		}))
+	default:
+		rows = append(rows, layout.Rigid(func() {
+			pe.LayoutEditor(gtx, th)
+		}))
+		rows = append(rows, layout.Rigid(func() {
+			pe.LayoutError(gtx, th)
+		}))
+		if pe.PC != nil {
+			rows = append(rows, layout.Rigid(func() {
+				path, file := pe.PC.Layout(gtx, th)
+				if path == "" {
+					return
+				}
+				pe.N.SetText(path)
+				pe.PC.Update(path, pe.P)
+				if file {
+					pe.store()
+					changed = true
+				}
+			}))
+		}
+	}
+	layout.Flex{Axis: layout.Vertical}.Layout(gtx, rows...)
+	return changed
+}
+
+// LayoutHover draws the parameter's label with w, followed by its
+// description while the pointer is over the label
+func (pe *ParamEdit) LayoutHover(gtx *layout.Context, th *material.Theme, h *Hover, w func()) {
+	d := pe.P.GetDescription()
+	show := false
+	layout.Flex{Axis: layout.Vertical}.Layout(gtx,
+		layout.Rigid(func() {
+			show = pe.hover.Layout(gtx, h, w) && d != ""
+		}),
+		layout.Rigid(func() {
+			if !show {
+				gtx.Dimensions = layout.Dimensions{}
+				return
+			}
+			th.Caption(d).Layout(gtx)
+		}))
+}
+
+// FormatValue returns the parameter's value as shown in a caption,
+// in its format and followed by its unit
+func (pe *ParamEdit) FormatValue() string {
+	s := rv.FormatParameterValue(pe.P)
+	if u := pe.P.GetMeta(rv.META_UNIT); u != "" {
+		s += " " + u
+	}
+	return s
+}
+
+// LayoutError draws the reason the editor's text was rejected, or
+// nothing if it was accepted
+func (pe *ParamEdit) LayoutError(gtx *layout.Context, th *material.Theme) {
+	if pe.Err == nil {
+		gtx.Dimensions = layout.Dimensions{}
+		return
+	}
+	msg := pe.Err.Error()
+	if e, ok := pe.Err.(*rv.ParseError); ok {
+		msg = e.Err.Error()
+	}
+	l := th.Caption(msg)
+	l.Color = errorColor
+	l.Layout(gtx)
+}
+
+// refreshIfDirty refreshes the widget under the model lock if its
+// parameter has changed behind its back
+func (pe *ParamEdit) refreshIfDirty() {
+	if pe.R != nil {
+		pe.R.Lock()
+		defer pe.R.Unlock()
+	}
+	if pe.dirty {
+		pe.Refresh()
+	}
+}
+
+// Refresh copies the current parameter value into the widget
+func (pe *ParamEdit) Refresh() {
+	pe.dirty = false
+	switch {
+	case pe.C != nil:
+		pe.C.SetChecked(pe.P.GetValueBool())
+	case pe.E != nil:
+		pe.E.SetValue(pe.P.GetValueString())
+	case pe.S != nil:
+		pe.S.SetValue(rv.GetParameterValueAsFloat64(pe.P))
+	case pe.K != nil:
+		c := pe.P.GetValueColor()
+		pe.K[0].SetValue(float64(c.R))
+		pe.K[1].SetValue(float64(c.G))
+		pe.K[2].SetValue(float64(c.B))
+		pe.K[3].SetValue(float64(c.A))
+	case pe.L != nil:
+		pe.L.SetItems(rv.GetListItems(pe.P))
+		pe.Err = nil
+	case pe.N != nil:
+		pe.N.SetText(rv.FormatParameterValue(pe.P))
+		pe.Err = nil
+	}
 }
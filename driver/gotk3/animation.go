@@ -0,0 +1,236 @@
+// Copyright 2020 Howard C. Shaw III. All rights reserved.
+// Use of this source code is governed by the MIT-license
+// as defined in the LICENSE file.
+
+// +build gotk3
+
+package gotk3
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	rv "github.com/TheGrum/renderview"
+
+	"github.com/gotk3/gotk3/glib"
+	"github.com/gotk3/gotk3/gtk"
+)
+
+// AnimationPanel is the sidebar's keyframe animation controls. Add
+// keyframe records the current parameters the given number of seconds
+// after the last keyframe; Play runs the timeline in the view, Open and
+// Save read and write it, and Export renders it to an animated GIF or a
+// PNG sequence.
+type AnimationPanel struct {
+	*gtk.Box
+
+	T *rv.Timeline
+
+	status *gtk.Label
+	gap    *gtk.Entry
+	play   *gtk.Button
+
+	// timer steps playback, and is removed when it stops
+	timer glib.SourceHandle
+}
+
+// NewAnimationPanel builds a panel for the model's timeline
+func NewAnimationPanel(w *GtkRenderWidget) *AnimationPanel {
+	box, err := gtk.BoxNew(gtk.ORIENTATION_VERTICAL, 1)
+	if err != nil {
+		log.Fatal(err)
+	}
+	status, err := gtk.LabelNew("")
+	if err != nil {
+		log.Fatal(err)
+	}
+	gap, err := gtk.EntryNew()
+	if err != nil {
+		log.Fatal(err)
+	}
+	play, err := gtk.ButtonNewWithLabel("Play")
+	if err != nil {
+		log.Fatal(err)
+	}
+	a := &AnimationPanel{
+		Box:    box,
+		T:      w.R.GetTimeline(),
+		status: status,
+		gap:    gap,
+		play:   play,
+	}
+	a.refresh()
+	a.T.OnChange(a.refresh)
+
+	gap.SetText("1")
+	gap.SetWidthChars(4)
+	gap.SetTooltipText("Seconds from the last keyframe to the next")
+	addButton, _ := gtk.ButtonNewWithLabel("Add keyframe")
+	clearButton, _ := gtk.ButtonNewWithLabel("Clear")
+	openButton, _ := gtk.ButtonNewWithLabel("Open…")
+	saveButton, _ := gtk.ButtonNewWithLabel("Save…")
+	exportButton, _ := gtk.ButtonNewWithLabel("Export…")
+
+	add := func() {
+		text, _ := gap.GetText()
+		seconds, err := strconv.ParseFloat(text, 64)
+		if err == nil {
+			err = a.T.AppendKeyframe(seconds)
+		}
+		if err != nil {
+			ShowErrorDialog(nil, err)
+		}
+	}
+	addButton.Connect("clicked", add)
+	gap.Connect("activate", add)
+	clearButton.Connect("clicked", a.T.Clear)
+	play.Connect("clicked", func() {
+		a.stopTimer()
+		if a.T.Playing() {
+			a.T.Stop()
+			play.SetLabel("Play")
+			return
+		}
+		if err := a.T.Play(); err != nil {
+			ShowErrorDialog(nil, err)
+			return
+		}
+		play.SetLabel("Stop")
+		timer, err := glib.TimeoutAdd(uint(a.T.Interval()/time.Millisecond), func() bool {
+			if !a.T.Step() {
+				a.timer = 0
+				play.SetLabel("Play")
+				return false
+			}
+			w.SetNeedsPaint()
+			return true
+		})
+		if err != nil {
+			a.T.Stop()
+			play.SetLabel("Play")
+			ShowErrorDialog(nil, err)
+			return
+		}
+		a.timer = timer
+	})
+	openButton.Connect("clicked", func() {
+		OpenTimelineDialog(nil, a.T)
+	})
+	saveButton.Connect("clicked", func() {
+		SaveTimelineDialog(nil, a.T)
+	})
+	exportButton.Connect("clicked", func() {
+		ExportAnimationDialog(nil, a.T)
+		w.SetNeedsPaint()
+	})
+
+	addRow, _ := gtk.BoxNew(gtk.ORIENTATION_HORIZONTAL, 1)
+	addRow.PackStart(gap, false, false, 0)
+	addRow.PackStart(addButton, true, true, 0)
+	row, _ := gtk.BoxNew(gtk.ORIENTATION_HORIZONTAL, 1)
+	row.SetHomogeneous(true)
+	row.PackStart(play, true, true, 0)
+	row.PackStart(clearButton, true, true, 0)
+	fileRow, _ := gtk.BoxNew(gtk.ORIENTATION_HORIZONTAL, 1)
+	fileRow.SetHomogeneous(true)
+	fileRow.PackStart(openButton, true, true, 0)
+	fileRow.PackStart(saveButton, true, true, 0)
+	fileRow.PackStart(exportButton, true, true, 0)
+
+	a.PackStart(status, false, false, 1)
+	a.PackStart(addRow, false, false, 1)
+	a.PackStart(row, false, false, 1)
+	a.PackStart(fileRow, false, false, 1)
+	return a
+}
+
+// stopTimer removes the playback timer if it is running
+func (a *AnimationPanel) stopTimer() {
+	if a.timer != 0 {
+		glib.SourceRemove(a.timer)
+		a.timer = 0
+	}
+}
+
+// refresh shows the number of keyframes and the length of the timeline
+func (a *AnimationPanel) refresh() {
+	a.status.SetText(fmt.Sprintf("%d keyframes, %gs", len(a.T.Keyframes()), a.T.Duration()))
+}
+
+func addTimelineFileFilters(fc *gtk.FileChooser) {
+	filter, err := gtk.FileFilterNew()
+	if err != nil {
+		log.Fatal(err)
+	}
+	filter.SetName("Timeline files")
+	filter.AddPattern("*.json")
+	fc.AddFilter(filter)
+	filter, err = gtk.FileFilterNew()
+	if err != nil {
+		log.Fatal(err)
+	}
+	filter.SetName("All files")
+	filter.AddPattern("*")
+	fc.AddFilter(filter)
+}
+
+// SaveTimelineDialog asks for a file name and saves the timeline to it
+func SaveTimelineDialog(parent *gtk.Window, t *rv.Timeline) {
+	dialog, err := gtk.FileChooserDialogNewWith2Buttons("Save Timeline", parent, gtk.FILE_CHOOSER_ACTION_SAVE,
+		"_Cancel", gtk.RESPONSE_CANCEL, "_Save", gtk.RESPONSE_ACCEPT)
+	if err != nil {
+		log.Fatal(err)
+	}
+	dialog.SetDoOverwriteConfirmation(true)
+	dialog.SetCurrentName(rv.DefaultTimelineFile())
+	addTimelineFileFilters(&dialog.FileChooser)
+	if dialog.Run() == gtk.RESPONSE_ACCEPT {
+		if err := t.Save(dialog.GetFilename()); err != nil {
+			ShowErrorDialog(parent, err)
+		}
+	}
+	dialog.Destroy()
+}
+
+// OpenTimelineDialog asks for a file and replaces the timeline with the
+// one saved in it
+func OpenTimelineDialog(parent *gtk.Window, t *rv.Timeline) {
+	dialog, err := gtk.FileChooserDialogNewWith2Buttons("Open Timeline", parent, gtk.FILE_CHOOSER_ACTION_OPEN,
+		"_Cancel", gtk.RESPONSE_CANCEL, "_Open", gtk.RESPONSE_ACCEPT)
+	if err != nil {
+		log.Fatal(err)
+	}
+	addTimelineFileFilters(&dialog.FileChooser)
+	if dialog.Run() == gtk.RESPONSE_ACCEPT {
+		if err := t.Load(dialog.GetFilename()); err != nil {
+			ShowErrorDialog(parent, err)
+		}
+	}
+	dialog.Destroy()
+}
+
+// ExportAnimationDialog asks for a file name and renders the timeline to
+// it, as an animated GIF for a name ending in .gif and otherwise as
+// numbered PNG files
+func ExportAnimationDialog(parent *gtk.Window, t *rv.Timeline) {
+	dialog, err := gtk.FileChooserDialogNewWith2Buttons("Export Animation", parent, gtk.FILE_CHOOSER_ACTION_SAVE,
+		"_Cancel", gtk.RESPONSE_CANCEL, "_Save", gtk.RESPONSE_ACCEPT)
+	if err != nil {
+		log.Fatal(err)
+	}
+	dialog.SetDoOverwriteConfirmation(true)
+	dialog.SetCurrentName(rv.DefaultAnimationFile())
+	name, ok := "", false
+	if dialog.Run() == gtk.RESPONSE_ACCEPT {
+		name, ok = dialog.GetFilename(), true
+	}
+	dialog.Destroy()
+	if !ok {
+		return
+	}
+	if err := t.Export(name); err != nil {
+		ShowErrorDialog(parent, err)
+	}
+}
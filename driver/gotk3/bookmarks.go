@@ -0,0 +1,140 @@
+// Copyright 2020 Howard C. Shaw III. All rights reserved.
+// Use of this source code is governed by the MIT-license
+// as defined in the LICENSE file.
+
+// +build gotk3
+
+package gotk3
+
+import (
+	"log"
+
+	rv "github.com/TheGrum/renderview"
+
+	"github.com/gotk3/gotk3/glib"
+	"github.com/gotk3/gotk3/gtk"
+)
+
+// BookmarkPanel is the sidebar's presets list. Choosing a bookmark jumps
+// to it; the entry and buttons add the current view or remove the chosen one.
+type BookmarkPanel struct {
+	*gtk.Box
+
+	B *rv.Bookmarks
+
+	combo      *gtk.ComboBoxText
+	entry      *gtk.Entry
+	refreshing bool
+	jumping    bool
+}
+
+// NewBookmarkPanel loads the model's bookmarks and builds a panel for them
+func NewBookmarkPanel(w *GtkRenderWidget) *BookmarkPanel {
+	box, err := gtk.BoxNew(gtk.ORIENTATION_VERTICAL, 1)
+	if err != nil {
+		log.Fatal(err)
+	}
+	combo, err := gtk.ComboBoxTextNew()
+	if err != nil {
+		log.Fatal(err)
+	}
+	entry, err := gtk.EntryNew()
+	if err != nil {
+		log.Fatal(err)
+	}
+	b := &BookmarkPanel{
+		Box:   box,
+		B:     w.R.GetBookmarks(),
+		combo: combo,
+		entry: entry,
+	}
+	if err := b.B.Load(); err != nil {
+		log.Printf("renderview: loading bookmarks: %v", err)
+	}
+	b.refresh()
+	b.B.OnChange(b.refresh)
+
+	goButton, _ := gtk.ButtonNewWithLabel("Go")
+	removeButton, _ := gtk.ButtonNewWithLabel("Remove")
+	addButton, _ := gtk.ButtonNewWithLabel("Add")
+	entry.SetTooltipText("Name for a bookmark of the current view")
+
+	combo.Connect("changed", func() {
+		if !b.refreshing {
+			b.jump(w)
+		}
+	})
+	goButton.Connect("clicked", func() {
+		b.jump(w)
+	})
+	removeButton.Connect("clicked", func() {
+		if name := combo.GetActiveText(); name != "" {
+			if err := b.B.Remove(name); err != nil {
+				ShowErrorDialog(nil, err)
+			}
+		}
+	})
+	add := func() {
+		name, _ := entry.GetText()
+		if err := b.B.Add(name); err != nil {
+			ShowErrorDialog(nil, err)
+			return
+		}
+		entry.SetText("")
+	}
+	addButton.Connect("clicked", add)
+	entry.Connect("activate", add)
+
+	row, _ := gtk.BoxNew(gtk.ORIENTATION_HORIZONTAL, 1)
+	row.SetHomogeneous(true)
+	row.PackStart(goButton, true, true, 0)
+	row.PackStart(removeButton, true, true, 0)
+	entryRow, _ := gtk.BoxNew(gtk.ORIENTATION_HORIZONTAL, 1)
+	entryRow.PackStart(entry, true, true, 0)
+	entryRow.PackStart(addButton, false, false, 0)
+
+	b.PackStart(combo, false, false, 1)
+	b.PackStart(row, false, false, 1)
+	b.PackStart(entryRow, false, false, 1)
+	return b
+}
+
+// refresh fills the list from the bookmarks, keeping the chosen one
+func (b *BookmarkPanel) refresh() {
+	b.refreshing = true
+	defer func() { b.refreshing = false }()
+	active := b.combo.GetActiveText()
+	b.combo.RemoveAll()
+	for i, name := range b.B.Names() {
+		b.combo.AppendText(name)
+		if name == active {
+			b.combo.SetActive(i)
+		}
+	}
+}
+
+// jump starts moving to the chosen bookmark, stepping the animation from
+// a timer until it arrives
+func (b *BookmarkPanel) jump(w *GtkRenderWidget) {
+	name := b.combo.GetActiveText()
+	if name == "" {
+		return
+	}
+	if err := b.B.Jump(name); err != nil {
+		ShowErrorDialog(nil, err)
+		return
+	}
+	w.SetNeedsPaint()
+	if b.jumping {
+		return
+	}
+	b.jumping = true
+	glib.TimeoutAdd(30, func() bool {
+		if !b.B.Step() {
+			b.jumping = false
+			return false
+		}
+		w.SetNeedsPaint()
+		return true
+	})
+}
@@ -0,0 +1,50 @@
+// Copyright 2020 Howard C. Shaw III. All rights reserved.
+// Use of this source code is governed by the MIT-license
+// as defined in the LICENSE file.
+
+// +build gotk3
+
+package gotk3
+
+import (
+	"github.com/gotk3/gotk3/gdk"
+	"github.com/gotk3/gotk3/gtk"
+)
+
+const (
+	SHIFT_MASK   uint = 1 << 0
+	CONTROL_MASK uint = 1 << 2
+	KEY_O        uint = 0x04f
+	KEY_S        uint = 0x053
+	KEY_Z        uint = 0x05a
+	KEY_o        uint = 0x06f
+	KEY_s        uint = 0x073
+	KEY_z        uint = 0x07a
+)
+
+// OnWindowKeyPress handles the shortcuts that work anywhere in the window:
+// Ctrl+S saves the parameters to a file and Ctrl+O loads them from one,
+// Ctrl+Z undoes the last change and Ctrl+Shift+Z redoes it
+func (w *GtkRenderWidget) OnWindowKeyPress(window *gtk.Window, ge *gdk.Event) bool {
+	e := &gdk.EventKey{ge}
+	state := e.State()
+	if state&CONTROL_MASK == 0 {
+		return false
+	}
+	switch e.KeyVal() {
+	case KEY_s, KEY_S:
+		SaveParametersDialog(window, w.R)
+	case KEY_o, KEY_O:
+		if OpenParametersDialog(window, w.R) {
+			w.SetNeedsPaint()
+		}
+	case KEY_z, KEY_Z:
+		h := w.R.GetHistory()
+		if state&SHIFT_MASK == 0 && h.Undo() || state&SHIFT_MASK != 0 && h.Redo() {
+			w.SetNeedsPaint()
+		}
+	default:
+		return false
+	}
+	return true
+}
@@ -8,6 +8,7 @@ package gotk3
 
 import (
 	"image"
+	"image/color"
 	"image/draw"
 	"log"
 
@@ -15,6 +16,7 @@ import (
 
 	"github.com/gotk3/gotk3/cairo"
 	"github.com/gotk3/gotk3/gdk"
+	"github.com/gotk3/gotk3/glib"
 	"github.com/gotk3/gotk3/gtk"
 )
 
@@ -32,7 +34,10 @@ func Main(m rv.RenderModel) {
 func GtkWindowInit(r rv.RenderModel) {
 	gtk.Init(nil)
 	window := GetGtkWindow(r, false)
+	// the widget has set the paint function that restoring may call
+	restoreSession(r)
 	window.Connect("destroy", func() {
+		saveSession(r)
 		gtk.MainQuit()
 	})
 
@@ -44,7 +49,10 @@ func GtkWindowInit(r rv.RenderModel) {
 func GtkWindowWithWidgetsInit(r rv.RenderModel) {
 	gtk.Init(nil)
 	window := GetGtkWindow(r, true)
+	// the widget has set the paint function that restoring may call
+	restoreSession(r)
 	window.Connect("destroy", func() {
+		saveSession(r)
 		gtk.MainQuit()
 	})
 
@@ -65,38 +73,130 @@ func GetGtkWindow(r rv.RenderModel, addWidgets bool) *gtk.Window {
 		child = WrapRenderWidget(render)
 	}
 	window.Add(child)
+	window.Connect("key-press-event", render.OnWindowKeyPress)
 	return window
 }
 
 func WrapRenderWidget(r *GtkRenderWidget) gtk.IWidget {
 	parent, _ := gtk.BoxNew(gtk.ORIENTATION_HORIZONTAL, 1)
 	sidebar, _ := gtk.BoxNew(gtk.ORIENTATION_VERTICAL, 1)
-	names := r.R.GetHintedParameterNamesWithFallback(rv.HINT_SIDEBAR | rv.HINT_FOOTER)
-	if len(names) > 0 {
-		label, _ := gtk.LabelNew("________Parameters________")
-		sidebar.PackStart(label, false, false, 1)
-
-		for i := 0; i < len(names); i++ {
-			label, _ = gtk.LabelNew(names[i])
-			sidebar.PackStart(label, false, false, 1)
-			tv := NewGtkParamWidget(r.R.GetParameter(names[i]), r)
+	// the bar stays in place when idle, so a render starting does not
+	// move the sidebar
+	progress, err := gtk.ProgressBarNew()
+	if err != nil {
+		log.Fatal(err)
+	}
+	progress.SetShowText(true)
+	r.Progress = progress
+	sidebar.PackStart(progress, false, false, 1)
+	for _, g := range r.R.GetParameterGroups(rv.HINT_SIDEBAR) {
+		box, _ := gtk.BoxNew(gtk.ORIENTATION_VERTICAL, 1)
+		for _, name := range g.Params {
+			p := r.R.GetParameter(name)
+			if !hasOwnLabel(p) {
+				// checkboxes carry their own label
+				label, _ := gtk.LabelNew(rv.GetParameterLabel(p))
+				label.SetTooltipText(p.GetDescription())
+				box.PackStart(label, false, false, 1)
+			}
+			tv := NewGtkParamWidget(p, r)
 			r.ParamWidgets = append(r.ParamWidgets, tv)
-			sidebar.PackStart(tv, false, false, 1)
+			box.PackStart(withUnit(tv, p), false, false, 1)
+			if tv.Message != nil {
+				box.PackStart(tv.Message, false, false, 1)
+			}
 		}
-
-		parent.PackStart(sidebar, false, true, 0)
+		sidebar.PackStart(newSection(g.Title(), box), false, false, 1)
 	}
-	names = r.R.GetHintedParameterNames(rv.HINT_FULLTEXT)
+	sidebar.PackStart(newSection("Bookmarks", NewBookmarkPanel(r)), false, false, 1)
+	sidebar.PackStart(newSection("Animation", NewAnimationPanel(r)), false, false, 1)
+	parent.PackStart(sidebar, false, true, 0)
+	names := r.R.GetHintedParameterNames(rv.HINT_FULLTEXT)
 	if len(names) > 0 {
 		// we can only do this for one parameter, so ignore multiples
 		tv := NewGtkParamWidget(r.R.GetParameter(names[0]), r)
 		r.ParamWidgets = append(r.ParamWidgets, tv)
-		parent.PackStart(tv, true, true, 1)
+		box, _ := gtk.BoxNew(gtk.ORIENTATION_VERTICAL, 1)
+		box.PackStart(tv, true, true, 0)
+		if tv.Message != nil {
+			box.PackStart(tv.Message, false, false, 0)
+		}
+		parent.PackStart(box, true, true, 1)
+	}
+	names = r.R.GetHintedParameterNames(rv.HINT_FOOTER)
+	if len(names) > 0 {
+		// the footer takes its height from the drawing area, so the
+		// width and height parameters report what is left for the image
+		box, _ := gtk.BoxNew(gtk.ORIENTATION_VERTICAL, 1)
+		box.PackStart(r, true, true, 0)
+		box.PackStart(NewFooter(r, names), false, false, 1)
+		parent.PackEnd(box, true, true, 2)
+	} else {
+		parent.PackEnd(r, true, true, 2)
 	}
-	parent.PackEnd(r, true, true, 2)
 	return parent
 }
 
+// NewFooter lays out the named parameters in a row of label and editor pairs
+func NewFooter(r *GtkRenderWidget, names []string) gtk.IWidget {
+	footer, _ := gtk.BoxNew(gtk.ORIENTATION_HORIZONTAL, 6)
+	for _, name := range names {
+		p := r.R.GetParameter(name)
+		tv := NewGtkParamWidget(p, r)
+		r.ParamWidgets = append(r.ParamWidgets, tv)
+		pair, _ := gtk.BoxNew(gtk.ORIENTATION_HORIZONTAL, 2)
+		if !hasOwnLabel(p) {
+			label, _ := gtk.LabelNew(rv.GetParameterLabel(p))
+			label.SetTooltipText(p.GetDescription())
+			pair.PackStart(label, false, false, 0)
+		}
+		if t, ok := tv.IWidget.(*gtk.TextView); ok {
+			t.SetSizeRequest(FOOTER_TEXT_WIDTH, -1)
+		}
+		pair.PackStart(withUnit(tv, p), true, true, 0)
+		cell, _ := gtk.BoxNew(gtk.ORIENTATION_VERTICAL, 0)
+		cell.PackStart(pair, false, false, 0)
+		if tv.Message != nil {
+			cell.PackStart(tv.Message, false, false, 0)
+		}
+		footer.PackStart(cell, false, false, 0)
+	}
+	return footer
+}
+
+// FOOTER_TEXT_WIDTH is the width in pixels of text editors in the footer
+const FOOTER_TEXT_WIDTH = 80
+
+// hasOwnLabel reports whether the widget for p shows its label itself,
+// as checkboxes do
+func hasOwnLabel(p rv.RenderParameter) bool {
+	return p.GetType() == "bool" && !rv.IsReadOnly(p)
+}
+
+// withUnit packs w beside a label showing the unit of p, if it has one
+func withUnit(w gtk.IWidget, p rv.RenderParameter) gtk.IWidget {
+	u := p.GetMeta(rv.META_UNIT)
+	if u == "" {
+		return w
+	}
+	box, _ := gtk.BoxNew(gtk.ORIENTATION_HORIZONTAL, 2)
+	box.PackStart(w, true, true, 0)
+	label, _ := gtk.LabelNew(u)
+	box.PackStart(label, false, false, 0)
+	return box
+}
+
+// newSection wraps child in an expanded Expander headed by title
+func newSection(title string, child gtk.IWidget) *gtk.Expander {
+	e, err := gtk.ExpanderNew(title)
+	if err != nil {
+		log.Fatal(err)
+	}
+	e.Add(child)
+	e.SetExpanded(true)
+	return e
+}
+
 type GtkRenderWidget struct {
 	*gtk.DrawingArea
 
@@ -106,30 +206,30 @@ type GtkRenderWidget struct {
 	index int
 	R     rv.RenderModel
 
-	left,
-	top,
-	right,
-	bottom,
 	width,
 	height,
-	zoom,
 	mouseX,
 	mouseY,
-	options,
-	page,
-	zoomRate rv.RenderParameter
+	page rv.RenderParameter
+
+	// view pans and zooms the model's viewport parameters
+	view *rv.Viewport
 
 	sx,
 	sy float64
 
-	leftIsFloat64,
-	zoomIsFloat64,
 	mouseIsDown,
 	dragging,
 	needsPaint bool
+	// needsUpdate is set when a parameter widget is dirty. Like the
+	// widgets' dirty flags it is guarded by the model lock, which is held
+	// whenever a parameter changes.
 	needsUpdate bool
 
 	ParamWidgets []*GtkParamWidget
+	// Progress shows how far a render has got, when there is a sidebar
+	Progress         *gtk.ProgressBar
+	watchingProgress bool
 }
 
 func NewGtkRenderWidget(r rv.RenderModel) *GtkRenderWidget {
@@ -139,20 +239,12 @@ func NewGtkRenderWidget(r rv.RenderModel) *GtkRenderWidget {
 		DrawingArea: i,
 		R:           r,
 	}
-	w.left = r.GetParameter("left")
-	w.top = r.GetParameter("top")
-	w.right = r.GetParameter("right")
-	w.bottom = r.GetParameter("bottom")
 	w.width = r.GetParameter("width")
 	w.height = r.GetParameter("height")
-	w.zoom = r.GetParameter("zoom")
 	w.mouseX = r.GetParameter("mouseX")
 	w.mouseY = r.GetParameter("mouseY")
-	w.options = r.GetParameter("options")
 	w.page = r.GetParameter("page")
-	w.zoomRate = r.GetParameter("zoomRate")
-	w.leftIsFloat64 = w.left.GetType() == "float64"
-	w.zoomIsFloat64 = w.zoom.GetType() == "float64"
+	w.view = rv.NewViewport(r)
 	w.Connect("draw", w.Draw)
 	w.Connect("configure-event", w.Configure)
 	w.Connect("motion-notify-event", w.OnMotion)
@@ -162,9 +254,13 @@ func NewGtkRenderWidget(r rv.RenderModel) *GtkRenderWidget {
 	w.Connect("key-press-event", w.OnKeyPress)
 	w.R.SetRequestPaintFunc(func() {
 		//w.UpdateParamWidgets()
-		w.needsUpdate = true
-		w.QueueDraw()
-		//w.GetWindow().Invalidate(nil, false)
+		// the renderer may call this from its own goroutine
+		glib.IdleAdd(w.refreshOutputs)
+		glib.IdleAdd(func() bool {
+			w.needsPaint = true
+			w.QueueDraw()
+			return false
+		})
 	})
 	w.SetCanFocus(true)
 	//	w.SetFocusOnClick(true) // missing?
@@ -177,19 +273,73 @@ func (w *GtkRenderWidget) SetNeedsPaint() {
 	w.QueueDraw()
 }
 
+// UpdateParamWidgets refreshes the widgets whose parameters have changed
+// behind their backs
 func (w *GtkRenderWidget) UpdateParamWidgets() {
 	w.R.Lock()
 	defer w.R.Unlock()
-	for i := 0; i < len(w.ParamWidgets); i++ {
-		w.ParamWidgets[i].Update()
+	if !w.needsUpdate {
+		return
 	}
 	w.needsUpdate = false
+	for i := 0; i < len(w.ParamWidgets); i++ {
+		if w.ParamWidgets[i].dirty {
+			w.ParamWidgets[i].dirty = false
+			w.ParamWidgets[i].Update()
+		}
+	}
+}
+
+// refreshOutputs updates the widgets of read-only parameters from the
+// main loop after the model requests a paint
+func (w *GtkRenderWidget) refreshOutputs() bool {
+	w.R.Lock()
+	for _, pw := range w.ParamWidgets {
+		if rv.IsReadOnly(pw.P) {
+			pw.dirty = true
+			w.needsUpdate = true
+		}
+	}
+	w.R.Unlock()
+	w.UpdateParamWidgets()
+	w.refreshProgress()
+	return false
+}
+
+// refreshProgress shows the progress of the render, updating it from a
+// timer while the render runs so the time left keeps counting down
+func (w *GtkRenderWidget) refreshProgress() {
+	if w.Progress == nil {
+		return
+	}
+	if w.showProgress() && !w.watchingProgress {
+		w.watchingProgress = true
+		glib.TimeoutAdd(250, func() bool {
+			w.watchingProgress = w.showProgress()
+			return w.watchingProgress
+		})
+	}
+}
+
+// showProgress sets the progress bar from the model, reporting whether a
+// render is running
+func (w *GtkRenderWidget) showProgress() bool {
+	p := rv.GetProgress(w.R)
+	if p.Reported() {
+		w.Progress.SetFraction(p.Fraction)
+	} else {
+		w.Progress.SetFraction(0)
+	}
+	w.Progress.SetText(p.String())
+	return p.Active
 }
 
 func (w *GtkRenderWidget) Configure() {
 	allocation := w.GetAllocation()
+	w.R.Lock()
 	w.width.SetValueInt(allocation.GetWidth())
 	w.height.SetValueInt(allocation.GetHeight())
+	w.R.Unlock()
 
 	var err error
 	w.pixbuf, err = gdk.PixbufNew(gdk.COLORSPACE_RGB, true, 8, allocation.GetWidth(), allocation.GetHeight())
@@ -197,13 +347,10 @@ func (w *GtkRenderWidget) Configure() {
 		log.Fatal(err)
 	}
 	w.needsPaint = true
-	w.needsUpdate = true
 }
 
 func (w *GtkRenderWidget) Draw(da *gtk.DrawingArea, cr *cairo.Context) {
-	if w.needsUpdate {
-		w.UpdateParamWidgets()
-	}
+	w.UpdateParamWidgets()
 	if w.needsPaint || w.Image == nil {
 		img := w.R.Render()
 		if img == nil {
@@ -235,6 +382,24 @@ func (w *GtkRenderWidget) Draw(da *gtk.DrawingArea, cr *cairo.Context) {
 	gtk.GdkCairoSetSourcePixBuf(cr, w.pixbuf, 0, 0)
 	cr.Paint()
 
+	// mark the picked points
+	w.R.Lock()
+	markers := rv.PickMarkers(w.R)
+	w.R.Unlock()
+	for _, pt := range markers {
+		outlines, arms := rv.CrosshairRects(pt)
+		cr.SetSourceRGB(0, 0, 0)
+		for _, r := range outlines {
+			cr.Rectangle(float64(r.Min.X), float64(r.Min.Y), float64(r.Dx()), float64(r.Dy()))
+		}
+		cr.Fill()
+		cr.SetSourceRGB(1, 1, 1)
+		for _, r := range arms {
+			cr.Rectangle(float64(r.Min.X), float64(r.Min.Y), float64(r.Dx()), float64(r.Dy()))
+		}
+		cr.Fill()
+	}
+
 	var err error
 	allocation := w.GetAllocation()
 	w.pixbuf, err = gdk.PixbufNew(gdk.COLORSPACE_RGB, true, 8, allocation.GetWidth(), allocation.GetHeight())
@@ -311,68 +476,10 @@ func GdkPixelCopyNRGBA(source *image.NRGBA, target *gdk.Pixbuf, region image.Rec
 
 func (w *GtkRenderWidget) OnScroll(da *gtk.DrawingArea, ge *gdk.Event) {
 	e := &gdk.EventScroll{ge}
-	if e.Direction() == gdk.SCROLL_DOWN {
-		if w.zoomIsFloat64 {
-			w.zoom.SetValueFloat64(w.zoom.GetValueFloat64() - 1)
-		} else {
-			w.zoom.SetValueInt(w.zoom.GetValueInt() - 1)
-		}
-		if w.options.GetValueInt()&rv.OPT_AUTO_ZOOM == rv.OPT_AUTO_ZOOM || w.options.GetValueInt()&rv.OPT_CENTER_ZOOM == rv.OPT_CENTER_ZOOM {
-			mult := 1 + rv.ZOOM_RATE
-			if w.zoomRate.GetValueFloat64() > 0 {
-				mult = 1 + w.zoomRate.GetValueFloat64()
-			}
-			if w.leftIsFloat64 {
-
-				zwidth := w.right.GetValueFloat64() - w.left.GetValueFloat64()
-				zheight := w.bottom.GetValueFloat64() - w.top.GetValueFloat64()
-				nzwidth := zwidth * mult
-				nzheight := zheight * mult
-				cx := float64(e.X()) / float64(w.width.GetValueInt())
-				cy := float64(e.Y()) / float64(w.height.GetValueInt())
-				if w.options.GetValueInt()&rv.OPT_CENTER_ZOOM == rv.OPT_CENTER_ZOOM {
-					cx = 0.5
-					cy = 0.5
-				}
-				//fmt.Printf("zoomOut: mult: %v zwidth: %v nzwidth: %v cx: %v left: %v nleft: %v\n", mult, zwidth, nzwidth, cx, left.GetValueFloat64(), left.GetValueFloat64()-((nzwidth-zwidth)*cx))
-				w.left.SetValueFloat64(w.left.GetValueFloat64() - ((nzwidth - zwidth) * cx))
-				w.top.SetValueFloat64(w.top.GetValueFloat64() - ((nzheight - zheight) * cy))
-				w.right.SetValueFloat64(w.left.GetValueFloat64() + nzwidth)
-				w.bottom.SetValueFloat64(w.top.GetValueFloat64() + nzheight)
-			}
-		}
-
-		//if gdk.ScrollDirection(e.Direction) == gdk.SCROLL_UP {
-	} else {
-		if w.zoomIsFloat64 {
-			w.zoom.SetValueFloat64(w.zoom.GetValueFloat64() + 1)
-		} else {
-			w.zoom.SetValueInt(w.zoom.GetValueInt() + 1)
-		}
-		if w.options.GetValueInt()&rv.OPT_AUTO_ZOOM == rv.OPT_AUTO_ZOOM || w.options.GetValueInt()&rv.OPT_CENTER_ZOOM == rv.OPT_CENTER_ZOOM {
-			mult := 1 - rv.ZOOM_RATE
-			if w.zoomRate.GetValueFloat64() > 0 {
-				mult = 1 - w.zoomRate.GetValueFloat64()
-			}
-			if w.leftIsFloat64 {
-				zwidth := w.right.GetValueFloat64() - w.left.GetValueFloat64()
-				zheight := w.bottom.GetValueFloat64() - w.top.GetValueFloat64()
-				nzwidth := zwidth * mult
-				nzheight := zheight * mult
-				cx := float64(e.X()) / float64(w.width.GetValueInt())
-				cy := float64(e.Y()) / float64(w.height.GetValueInt())
-				if w.options.GetValueInt()&rv.OPT_CENTER_ZOOM == rv.OPT_CENTER_ZOOM {
-					cx = 0.5
-					cy = 0.5
-				}
-				w.left.SetValueFloat64(w.left.GetValueFloat64() - ((nzwidth - zwidth) * cx))
-				w.top.SetValueFloat64(w.top.GetValueFloat64() - ((nzheight - zheight) * cy))
-				w.right.SetValueFloat64(w.left.GetValueFloat64() + nzwidth)
-				w.bottom.SetValueFloat64(w.top.GetValueFloat64() + nzheight)
-			}
-		}
-	}
-	w.needsUpdate = true
+	w.R.GetHistory().Checkpoint("zoom")
+	w.R.Lock()
+	w.view.ZoomAt(e.X(), e.Y(), e.Direction() == gdk.SCROLL_DOWN)
+	w.R.Unlock()
 	w.SetNeedsPaint()
 
 }
@@ -382,8 +489,10 @@ func (w *GtkRenderWidget) OnMotion(da *gtk.DrawingArea, ge *gdk.Event) {
 	//	fmt.Printf("Motion: X:%v Y:%v sx:%v sy:%v mouseIsDown:%v dragging:%v\n", e.X, e.Y, w.sx, w.sy, w.mouseIsDown, w.dragging)
 	var X, Y float64
 	X, Y = e.MotionVal()
+	w.R.Lock()
 	w.mouseX.SetValueFloat64(X)
 	w.mouseY.SetValueFloat64(Y)
+	w.R.Unlock()
 
 	if w.mouseIsDown && w.dragging == false {
 		if ((X - w.sx) > 3) || ((w.sx - X) > 3) || ((Y - w.sy) > 3) || ((w.sy - Y) > 3) {
@@ -392,32 +501,11 @@ func (w *GtkRenderWidget) OnMotion(da *gtk.DrawingArea, ge *gdk.Event) {
 	}
 
 	if w.dragging {
-		if w.leftIsFloat64 {
-			width := w.right.GetValueFloat64() - w.left.GetValueFloat64()
-			height := w.bottom.GetValueFloat64() - w.top.GetValueFloat64()
-			dx := width / float64(w.width.GetValueInt())
-			dy := height / float64(w.height.GetValueInt())
-			cx := float64(X-w.sx) * dx
-			cy := float64(Y-w.sy) * dy
-			//						fmt.Printf("dx %v dy %v cx %v cy %v\n", dx, dy, cx, cy)
-			w.left.SetValueFloat64(w.left.GetValueFloat64() - cx)
-			w.right.SetValueFloat64(w.right.GetValueFloat64() - cx)
-			w.top.SetValueFloat64(w.top.GetValueFloat64() - cy)
-			w.bottom.SetValueFloat64(w.bottom.GetValueFloat64() - cy)
-		} else {
-			width := w.right.GetValueInt() - w.left.GetValueInt()
-			height := w.bottom.GetValueInt() - w.top.GetValueInt()
-			dx := float64(width) / float64(w.width.GetValueInt())
-			dy := float64(height) / float64(w.height.GetValueInt())
-			cx := float64(X-w.sx) * dx
-			cy := float64(Y-w.sy) * dy
-			w.left.SetValueInt(int(float64(w.left.GetValueInt()) - cx))
-			w.right.SetValueInt(int(float64(w.right.GetValueInt()) - cx))
-			w.top.SetValueInt(int(float64(w.top.GetValueInt()) - cy))
-			w.bottom.SetValueInt(int(float64(w.bottom.GetValueInt()) - cy))
-		}
+		w.R.GetHistory().Checkpoint("pan")
+		w.R.Lock()
+		w.view.Pan(X-w.sx, Y-w.sy)
+		w.R.Unlock()
 		//			Draw(r.Render(), buf.RGBA())
-		w.needsUpdate = true
 		w.SetNeedsPaint()
 
 		w.sx = X
@@ -430,8 +518,10 @@ func (w *GtkRenderWidget) OnMotion(da *gtk.DrawingArea, ge *gdk.Event) {
 func (w *GtkRenderWidget) OnButton(da *gtk.DrawingArea, ge *gdk.Event) {
 	e := &gdk.EventButton{ge}
 	//	fmt.Printf("Button called with %v\n", e)i
+	w.R.Lock()
 	w.mouseX.SetValueFloat64(e.X())
 	w.mouseY.SetValueFloat64(e.Y())
+	w.R.Unlock()
 	w.GrabFocus()
 
 	if gdk.EventType(e.Type()) == gdk.EVENT_BUTTON_PRESS {
@@ -444,6 +534,10 @@ func (w *GtkRenderWidget) OnButton(da *gtk.DrawingArea, ge *gdk.Event) {
 	}
 	if gdk.EventType(e.Type()) == gdk.EVENT_BUTTON_RELEASE {
 		//		fmt.Println("Mouseup")
+		// a click, rather than the end of a drag, picks a point
+		if w.mouseIsDown && !w.dragging && rv.PickAt(w.R, e.X(), e.Y()) {
+			w.SetNeedsPaint()
+		}
 		w.mouseIsDown = false
 		w.dragging = false
 	}
@@ -457,13 +551,17 @@ const (
 func (w *GtkRenderWidget) OnKeyPress(da *gtk.DrawingArea, ge *gdk.Event) {
 	e := &gdk.EventKey{ge}
 	if e.KeyVal() == PAGE_UP {
+		w.R.GetHistory().Checkpoint("page")
+		w.R.Lock()
 		w.page.SetValueInt(w.page.GetValueInt() - 1)
-		w.needsUpdate = true
+		w.R.Unlock()
 		w.SetNeedsPaint()
 	}
 	if e.KeyVal() == PAGE_DOWN {
+		w.R.GetHistory().Checkpoint("page")
+		w.R.Lock()
 		w.page.SetValueInt(w.page.GetValueInt() + 1)
-		w.needsUpdate = true
+		w.R.Unlock()
 		w.SetNeedsPaint()
 	}
 }
@@ -471,9 +569,83 @@ func (w *GtkRenderWidget) OnKeyPress(da *gtk.DrawingArea, ge *gdk.Event) {
 type GtkParamWidget struct {
 	gtk.IWidget
 	P rv.RenderParameter
+
+	// Message, if present, is shown below the widget when input is rejected
+	Message *gtk.Label
+
+	// dirty is set when the parameter changes behind the widget's back,
+	// editing while the widget itself is writing the parameter, and
+	// updating while Update is writing the widget
+	dirty    bool
+	editing  bool
+	updating bool
+	model    rv.RenderModel
+	history  *rv.History
 }
 
 func NewGtkParamWidget(p rv.RenderParameter, w *GtkRenderWidget) *GtkParamWidget {
+	var r *GtkParamWidget
+	if rv.IsReadOnly(p) {
+		r = NewGtkOutputParamWidget(p)
+	} else {
+		switch p.GetType() {
+		case "bool":
+			r = NewGtkCheckParamWidget(p, w)
+		case "choice":
+			r = NewGtkComboParamWidget(p, w)
+		case "path":
+			r = NewGtkPathParamWidget(p, w)
+		case "[]int", "[]float64", "[]string":
+			r = NewGtkListParamWidget(p, w)
+		case "color":
+			r = NewGtkColorParamWidget(p, w)
+		case "int", "float64":
+			if _, _, _, bounded := p.GetRange(); bounded {
+				r = NewGtkScaleParamWidget(p, w)
+			} else {
+				r = NewGtkTextParamWidget(p, w)
+			}
+		default:
+			r = NewGtkTextParamWidget(p, w)
+		}
+	}
+	r.model = w.R
+	r.history = w.R.GetHistory()
+	if d := p.GetDescription(); d != "" {
+		if t, ok := r.IWidget.(interface{ SetTooltipText(string) }); ok {
+			t.SetTooltipText(d)
+		}
+	}
+	p.OnChange(func(oldValue interface{}, newValue interface{}) {
+		if !r.editing {
+			r.dirty = true
+			w.needsUpdate = true
+		}
+	})
+	return r
+}
+
+// set runs f, which writes the parameter from the widget, under the
+// model lock and without marking the widget for refresh, recording the
+// change in the history. The widget's own signals during Update are ignored.
+func (r *GtkParamWidget) set(f func()) {
+	if r.updating {
+		return
+	}
+	if r.history != nil {
+		r.history.Checkpoint(r.P.GetName())
+	}
+	if r.model != nil {
+		r.model.Lock()
+		defer r.model.Unlock()
+	}
+	r.editing = true
+	f()
+	r.editing = false
+}
+
+// NewGtkTextParamWidget edits the string form of any parameter in a TextView
+func NewGtkTextParamWidget(p rv.RenderParameter, w *GtkRenderWidget) *GtkParamWidget {
 	tv, err := gtk.TextViewNew()
 	if err != nil {
 		log.Fatal(err)
@@ -482,13 +654,21 @@ func NewGtkParamWidget(p rv.RenderParameter, w *GtkRenderWidget) *GtkParamWidget
 		IWidget: tv,
 		P:       p,
 	}
+	r.Message, err = gtk.LabelNew("")
+	if err != nil {
+		log.Fatal(err)
+	}
+	r.Message.SetNoShowAll(true)
+	r.Message.SetLineWrap(true)
+	addErrorStyle(&tv.Widget)
+	addErrorStyle(&r.Message.Widget)
 	tv.SetEditable(true)
 	tv.SetCursorVisible(true)
 	tb, err := tv.GetBuffer()
 	if err != nil {
 		log.Fatal(err)
 	}
-	tb.SetText(rv.GetParameterValueAsString(p))
+	tb.SetText(rv.FormatParameterValue(p))
 	tb.Connect("changed", func() {
 		var start, end *gtk.TextIter
 		start, end = tb.GetBounds()
@@ -496,23 +676,340 @@ func NewGtkParamWidget(p rv.RenderParameter, w *GtkRenderWidget) *GtkParamWidget
 		if err != nil {
 			log.Fatal(err)
 		}
-		pValue := rv.GetParameterValueAsString(r.P)
+		pValue := rv.FormatParameterValue(r.P)
 		if s != pValue {
-			rv.SetParameterValueFromString(r.P, s)
+			r.set(func() { err = rv.SetParameterValueFromString(r.P, s) })
+		}
+		r.ShowError(err)
+		w.SetNeedsPaint()
+	})
+	return r
+}
+
+// NewGtkOutputParamWidget shows a read-only parameter in a Label, which
+// is refreshed whenever the model requests a paint
+func NewGtkOutputParamWidget(p rv.RenderParameter) *GtkParamWidget {
+	l, err := gtk.LabelNew(rv.FormatParameterValue(p))
+	if err != nil {
+		log.Fatal(err)
+	}
+	l.SetHAlign(gtk.ALIGN_START)
+	l.SetSelectable(true)
+	return &GtkParamWidget{
+		IWidget: l,
+		P:       p,
+	}
+}
+
+// NewGtkCheckParamWidget edits a bool parameter with a CheckButton
+func NewGtkCheckParamWidget(p rv.RenderParameter, w *GtkRenderWidget) *GtkParamWidget {
+	cb, err := gtk.CheckButtonNewWithLabel(rv.GetParameterLabel(p))
+	if err != nil {
+		log.Fatal(err)
+	}
+	r := &GtkParamWidget{
+		IWidget: cb,
+		P:       p,
+	}
+	cb.SetActive(p.GetValueBool())
+	cb.Connect("toggled", func() {
+		if cb.GetActive() != r.P.GetValueBool() {
+			r.set(func() { r.P.SetValueBool(cb.GetActive()) })
+			w.SetNeedsPaint()
+		}
+	})
+	return r
+}
+
+// NewGtkComboParamWidget edits a choice parameter with a ComboBox
+// listing the allowed values
+func NewGtkComboParamWidget(p rv.RenderParameter, w *GtkRenderWidget) *GtkParamWidget {
+	cb, err := gtk.ComboBoxTextNew()
+	if err != nil {
+		log.Fatal(err)
+	}
+	r := &GtkParamWidget{
+		IWidget: cb,
+		P:       p,
+	}
+	for _, c := range p.GetChoices() {
+		cb.AppendText(c)
+	}
+	r.Update()
+	cb.Connect("changed", func() {
+		s := cb.GetActiveText()
+		if s != "" && s != r.P.GetValueString() {
+			r.set(func() { r.P.SetValueString(s) })
+			w.SetNeedsPaint()
+		}
+	})
+	return r
+}
+
+// GtkPathChooser is an Entry for a path beside a button opening a file chooser
+type GtkPathChooser struct {
+	*gtk.Box
+	Entry  *gtk.Entry
+	Browse *gtk.Button
+}
+
+// NewGtkPathParamWidget edits a path parameter with a GtkPathChooser. A
+// typed path is applied when Enter is pressed or the entry loses focus.
+func NewGtkPathParamWidget(p rv.RenderParameter, w *GtkRenderWidget) *GtkParamWidget {
+	box, err := gtk.BoxNew(gtk.ORIENTATION_HORIZONTAL, 1)
+	if err != nil {
+		log.Fatal(err)
+	}
+	entry, err := gtk.EntryNew()
+	if err != nil {
+		log.Fatal(err)
+	}
+	browse, err := gtk.ButtonNewWithLabel("Browse…")
+	if err != nil {
+		log.Fatal(err)
+	}
+	pc := &GtkPathChooser{
+		Box:    box,
+		Entry:  entry,
+		Browse: browse,
+	}
+	pc.PackStart(entry, true, true, 0)
+	pc.PackStart(browse, false, false, 0)
+	r := &GtkParamWidget{
+		IWidget: pc,
+		P:       p,
+	}
+	r.Update()
+	apply := func(s string) {
+		if s == r.P.GetValueString() {
+			return
+		}
+		var err error
+		r.set(func() { err = rv.SetParameterValueFromString(r.P, s) })
+		if err != nil {
+			ShowErrorDialog(nil, err)
+			r.Update()
+			return
+		}
+		w.SetNeedsPaint()
+	}
+	entry.Connect("activate", func() {
+		s, _ := entry.GetText()
+		apply(s)
+	})
+	entry.Connect("focus-out-event", func() {
+		s, _ := entry.GetText()
+		apply(s)
+	})
+	browse.Connect("clicked", func() {
+		if path, ok := ChoosePathDialog(nil, r.P); ok {
+			entry.SetText(path)
+			apply(path)
+		}
+	})
+	return r
+}
+
+// GtkListEditor is a row per item of a list, an Entry with buttons to
+// move the item up or down or remove it, above a button adding an item
+type GtkListEditor struct {
+	*gtk.Box
+	Add     *gtk.Button
+	Entries []*gtk.Entry
+	rows    []*gtk.Box
+	apply   func(items []string)
+}
+
+// Items returns the text of the entries
+func (le *GtkListEditor) Items() []string {
+	items := make([]string, len(le.Entries))
+	for i, e := range le.Entries {
+		items[i], _ = e.GetText()
+	}
+	return items
+}
+
+// SetItems replaces the rows with one per item
+func (le *GtkListEditor) SetItems(items []string) {
+	for _, row := range le.rows {
+		le.Remove(row)
+	}
+	le.rows = nil
+	le.Entries = nil
+	button := func(label string, f func(l []string) []string) *gtk.Button {
+		b, err := gtk.ButtonNewWithLabel(label)
+		if err != nil {
+			log.Fatal(err)
+		}
+		// keep the entries' focus-out from rebuilding the rows mid-click
+		b.SetFocusOnClick(false)
+		b.Connect("clicked", func() {
+			le.apply(f(le.Items()))
+		})
+		return b
+	}
+	for i, item := range items {
+		i := i
+		row, err := gtk.BoxNew(gtk.ORIENTATION_HORIZONTAL, 1)
+		if err != nil {
+			log.Fatal(err)
+		}
+		e, err := gtk.EntryNew()
+		if err != nil {
+			log.Fatal(err)
+		}
+		e.SetText(item)
+		e.Connect("activate", func() {
+			le.apply(le.Items())
+		})
+		e.Connect("focus-out-event", func() {
+			le.apply(le.Items())
+		})
+		up := button("↑", func(l []string) []string {
+			l[i-1], l[i] = l[i], l[i-1]
+			return l
+		})
+		up.SetSensitive(i > 0)
+		down := button("↓", func(l []string) []string {
+			l[i], l[i+1] = l[i+1], l[i]
+			return l
+		})
+		down.SetSensitive(i < len(items)-1)
+		remove := button("✕", func(l []string) []string {
+			return append(l[:i], l[i+1:]...)
+		})
+		row.PackStart(e, true, true, 0)
+		row.PackStart(up, false, false, 0)
+		row.PackStart(down, false, false, 0)
+		row.PackStart(remove, false, false, 0)
+		le.PackStart(row, false, false, 0)
+		row.ShowAll()
+		le.rows = append(le.rows, row)
+		le.Entries = append(le.Entries, e)
+	}
+}
+
+// NewGtkListParamWidget edits a list parameter with a GtkListEditor. An
+// edited item is applied when Enter is pressed or its entry loses focus.
+func NewGtkListParamWidget(p rv.RenderParameter, w *GtkRenderWidget) *GtkParamWidget {
+	box, err := gtk.BoxNew(gtk.ORIENTATION_VERTICAL, 1)
+	if err != nil {
+		log.Fatal(err)
+	}
+	add, err := gtk.ButtonNewWithLabel("Add")
+	if err != nil {
+		log.Fatal(err)
+	}
+	le := &GtkListEditor{
+		Box: box,
+		Add: add,
+	}
+	le.PackEnd(add, false, false, 0)
+	r := &GtkParamWidget{
+		IWidget: le,
+		P:       p,
+	}
+	le.apply = func(items []string) {
+		if r.updating || rv.FormatList(items) == rv.FormatList(rv.GetListItems(r.P)) {
+			return
+		}
+		var err error
+		r.set(func() { err = rv.SetListItems(r.P, items) })
+		if err != nil {
+			ShowErrorDialog(nil, err)
 		}
+		r.Update()
+		w.SetNeedsPaint()
+	}
+	add.Connect("clicked", func() {
+		items := le.Items()
+		le.apply(append(items, rv.NewListItem(r.P, items)))
+	})
+	r.Update()
+	return r
+}
+
+// NewGtkScaleParamWidget edits a bounded int or float64 parameter with a
+// Scale, which can be dragged or stepped with the mouse wheel
+func NewGtkScaleParamWidget(p rv.RenderParameter, w *GtkRenderWidget) *GtkParamWidget {
+	min, max, step, _ := p.GetRange()
+	if step <= 0 {
+		step = (max - min) / 100
+	}
+	sc, err := gtk.ScaleNewWithRange(gtk.ORIENTATION_HORIZONTAL, min, max, step)
+	if err != nil {
+		log.Fatal(err)
+	}
+	r := &GtkParamWidget{
+		IWidget: sc,
+		P:       p,
+	}
+	r.Update()
+	sc.Connect("value-changed", func() {
+		v := sc.GetValue()
+		if v != rv.GetParameterValueAsFloat64(r.P) {
+			r.set(func() { rv.SetParameterValueFromFloat64(r.P, v) })
+			w.SetNeedsPaint()
+		}
+	})
+	return r
+}
+
+// NewGtkColorParamWidget edits a color parameter with a ColorButton
+// opening the native color chooser
+func NewGtkColorParamWidget(p rv.RenderParameter, w *GtkRenderWidget) *GtkParamWidget {
+	cb, err := gtk.ColorButtonNew()
+	if err != nil {
+		log.Fatal(err)
+	}
+	r := &GtkParamWidget{
+		IWidget: cb,
+		P:       p,
+	}
+	cb.SetUseAlpha(true)
+	r.Update()
+	cb.Connect("color-set", func() {
+		f := cb.GetRGBA().Floats()
+		r.set(func() {
+			r.P.SetValueColor(color.RGBA{uint8(f[0]*255 + 0.5), uint8(f[1]*255 + 0.5), uint8(f[2]*255 + 0.5), uint8(f[3]*255 + 0.5)})
+		})
 		w.SetNeedsPaint()
 	})
 	return r
 }
 
 func (w *GtkParamWidget) Update() {
+	w.updating = true
+	defer func() { w.updating = false }()
 	switch a := w.IWidget.(type) {
 	case *gtk.TextView:
 		tb, err := a.GetBuffer()
 		if err != nil {
 			log.Fatal(err)
 		}
-		tb.SetText(rv.GetParameterValueAsString(w.P))
+		tb.SetText(rv.FormatParameterValue(w.P))
+		w.ShowError(nil)
+	case *gtk.Label:
+		a.SetText(rv.FormatParameterValue(w.P))
+	case *gtk.CheckButton:
+		a.SetActive(w.P.GetValueBool())
+	case *GtkListEditor:
+		a.SetItems(rv.GetListItems(w.P))
+	case *GtkPathChooser:
+		a.Entry.SetText(w.P.GetValueString())
+	case *gtk.Scale:
+		a.SetValue(rv.GetParameterValueAsFloat64(w.P))
+	case *gtk.ColorButton:
+		c := w.P.GetValueColor()
+		a.SetRGBA(gdk.NewRGBA(float64(c.R)/255, float64(c.G)/255, float64(c.B)/255, float64(c.A)/255))
+	case *gtk.ComboBoxText:
+		v := w.P.GetValueString()
+		for i, c := range w.P.GetChoices() {
+			if c == v {
+				a.SetActive(i)
+				break
+			}
+		}
 	}
 }
 
@@ -521,3 +1018,64 @@ func handleError(err error) {
 		log.Fatal(err)
 	}
 }
+
+// errorCSS styles widgets carrying the rv-error class
+const errorCSS = `
+textview.rv-error text { background-color: #ffd0d0; }
+label.rv-error { color: #c00000; }
+`
+
+var errorProvider *gtk.CssProvider
+
+func addErrorStyle(w *gtk.Widget) {
+	if errorProvider == nil {
+		var err error
+		errorProvider, err = gtk.CssProviderNew()
+		if err != nil {
+			log.Fatal(err)
+		}
+		if err = errorProvider.LoadFromData(errorCSS); err != nil {
+			log.Fatal(err)
+		}
+	}
+	sc, err := w.GetStyleContext()
+	if err != nil {
+		log.Fatal(err)
+	}
+	sc.AddProvider(errorProvider, gtk.STYLE_PROVIDER_PRIORITY_APPLICATION)
+}
+
+func setErrorClass(w *gtk.Widget, on bool) {
+	sc, err := w.GetStyleContext()
+	if err != nil {
+		log.Fatal(err)
+	}
+	if on {
+		sc.AddClass("rv-error")
+	} else {
+		sc.RemoveClass("rv-error")
+	}
+}
+
+// ShowError marks the text widget as in error and shows err below it, or
+// clears the error state if err is nil. The parameter keeps its last good value.
+func (w *GtkParamWidget) ShowError(err error) {
+	tv, ok := w.IWidget.(*gtk.TextView)
+	if !ok || w.Message == nil {
+		return
+	}
+	setErrorClass(&tv.Widget, err != nil)
+	setErrorClass(&w.Message.Widget, err != nil)
+	if err == nil {
+		tv.SetTooltipText(w.P.GetDescription())
+		w.Message.Hide()
+		return
+	}
+	msg := err.Error()
+	if pe, ok := err.(*rv.ParseError); ok {
+		msg = pe.Err.Error()
+	}
+	tv.SetTooltipText(err.Error())
+	w.Message.SetText(msg)
+	w.Message.Show()
+}
@@ -0,0 +1,190 @@
+// Copyright 2020 Howard C. Shaw III. All rights reserved.
+// Use of this source code is governed by the MIT-license
+// as defined in the LICENSE file.
+
+// +build !gotk3,!nogtk2 !shiny,!nogtk2
+
+package gtk2
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	rv "github.com/TheGrum/renderview"
+
+	"github.com/mattn/go-gtk/glib"
+	"github.com/mattn/go-gtk/gtk"
+)
+
+// AnimationPanel is the sidebar's keyframe animation controls. Add
+// keyframe records the current parameters the given number of seconds
+// after the last keyframe; Play runs the timeline in the view, Open and
+// Save read and write it, and Export renders it to an animated GIF or a
+// PNG sequence.
+type AnimationPanel struct {
+	*gtk.VBox
+
+	T *rv.Timeline
+
+	status *gtk.Label
+	gap    *gtk.Entry
+	play   *gtk.Button
+
+	// run counts the times Play was pressed, so the timer of an earlier
+	// run stops rather than stepping alongside the current one
+	run int
+}
+
+// NewAnimationPanel builds a panel for the model's timeline
+func NewAnimationPanel(w *GtkRenderWidget) *AnimationPanel {
+	a := &AnimationPanel{
+		VBox:   gtk.NewVBox(false, 1),
+		T:      w.R.GetTimeline(),
+		status: gtk.NewLabel(""),
+		gap:    gtk.NewEntry(),
+		play:   gtk.NewButtonWithLabel("Play"),
+	}
+	a.refresh()
+	a.T.OnChange(a.refresh)
+
+	a.gap.SetText("1")
+	a.gap.SetWidthChars(4)
+	a.gap.SetTooltipText("Seconds from the last keyframe to the next")
+	addButton := gtk.NewButtonWithLabel("Add keyframe")
+	clearButton := gtk.NewButtonWithLabel("Clear")
+	openButton := gtk.NewButtonWithLabel("Open…")
+	saveButton := gtk.NewButtonWithLabel("Save…")
+	exportButton := gtk.NewButtonWithLabel("Export…")
+
+	add := func() {
+		gap, err := strconv.ParseFloat(a.gap.GetText(), 64)
+		if err == nil {
+			err = a.T.AppendKeyframe(gap)
+		}
+		if err != nil {
+			ShowErrorDialog(nil, err)
+		}
+	}
+	addButton.Clicked(add)
+	a.gap.Connect("activate", add)
+	clearButton.Clicked(a.T.Clear)
+	a.play.Clicked(func() {
+		a.run++
+		if a.T.Playing() {
+			a.T.Stop()
+			a.play.SetLabel("Play")
+			return
+		}
+		if err := a.T.Play(); err != nil {
+			ShowErrorDialog(nil, err)
+			return
+		}
+		a.play.SetLabel("Stop")
+		run := a.run
+		glib.TimeoutAdd(uint(a.T.Interval()/time.Millisecond), func() bool {
+			if run != a.run {
+				return false
+			}
+			if !a.T.Step() {
+				a.play.SetLabel("Play")
+				return false
+			}
+			w.SetNeedsPaint()
+			return true
+		})
+	})
+	openButton.Clicked(func() {
+		OpenTimelineDialog(nil, a.T)
+	})
+	saveButton.Clicked(func() {
+		SaveTimelineDialog(nil, a.T)
+	})
+	exportButton.Clicked(func() {
+		ExportAnimationDialog(nil, a.T)
+		w.SetNeedsPaint()
+	})
+
+	addRow := gtk.NewHBox(false, 1)
+	addRow.PackStart(a.gap, false, false, 0)
+	addRow.PackStart(addButton, true, true, 0)
+	row := gtk.NewHBox(true, 1)
+	row.PackStart(a.play, true, true, 0)
+	row.PackStart(clearButton, true, true, 0)
+	fileRow := gtk.NewHBox(true, 1)
+	fileRow.PackStart(openButton, true, true, 0)
+	fileRow.PackStart(saveButton, true, true, 0)
+	fileRow.PackStart(exportButton, true, true, 0)
+
+	a.PackStart(a.status, false, false, 1)
+	a.PackStart(addRow, false, false, 1)
+	a.PackStart(row, false, false, 1)
+	a.PackStart(fileRow, false, false, 1)
+	return a
+}
+
+// refresh shows the number of keyframes and the length of the timeline
+func (a *AnimationPanel) refresh() {
+	a.status.SetText(fmt.Sprintf("%d keyframes, %gs", len(a.T.Keyframes()), a.T.Duration()))
+}
+
+func addTimelineFileFilters(fc *gtk.FileChooser) {
+	filter := gtk.NewFileFilter()
+	filter.SetName("Timeline files")
+	filter.AddPattern("*.json")
+	fc.AddFilter(filter)
+	filter = gtk.NewFileFilter()
+	filter.SetName("All files")
+	filter.AddPattern("*")
+	fc.AddFilter(filter)
+}
+
+// SaveTimelineDialog asks for a file name and saves the timeline to it
+func SaveTimelineDialog(parent *gtk.Window, t *rv.Timeline) {
+	dialog := gtk.NewFileChooserDialog("Save Timeline", parent, gtk.FILE_CHOOSER_ACTION_SAVE,
+		gtk.STOCK_CANCEL, gtk.RESPONSE_CANCEL, gtk.STOCK_SAVE, gtk.RESPONSE_ACCEPT)
+	dialog.SetDoOverwriteConfirmation(true)
+	dialog.SetCurrentName(rv.DefaultTimelineFile())
+	addTimelineFileFilters(&dialog.FileChooser)
+	if dialog.Run() == gtk.RESPONSE_ACCEPT {
+		if err := t.Save(dialog.GetFilename()); err != nil {
+			ShowErrorDialog(parent, err)
+		}
+	}
+	dialog.Destroy()
+}
+
+// OpenTimelineDialog asks for a file and replaces the timeline with the
+// one saved in it
+func OpenTimelineDialog(parent *gtk.Window, t *rv.Timeline) {
+	dialog := gtk.NewFileChooserDialog("Open Timeline", parent, gtk.FILE_CHOOSER_ACTION_OPEN,
+		gtk.STOCK_CANCEL, gtk.RESPONSE_CANCEL, gtk.STOCK_OPEN, gtk.RESPONSE_ACCEPT)
+	addTimelineFileFilters(&dialog.FileChooser)
+	if dialog.Run() == gtk.RESPONSE_ACCEPT {
+		if err := t.Load(dialog.GetFilename()); err != nil {
+			ShowErrorDialog(parent, err)
+		}
+	}
+	dialog.Destroy()
+}
+
+// ExportAnimationDialog asks for a file name and renders the timeline to
+// it, as an animated GIF for a name ending in .gif and otherwise as
+// numbered PNG files
+func ExportAnimationDialog(parent *gtk.Window, t *rv.Timeline) {
+	dialog := gtk.NewFileChooserDialog("Export Animation", parent, gtk.FILE_CHOOSER_ACTION_SAVE,
+		gtk.STOCK_CANCEL, gtk.RESPONSE_CANCEL, gtk.STOCK_SAVE, gtk.RESPONSE_ACCEPT)
+	dialog.SetDoOverwriteConfirmation(true)
+	dialog.SetCurrentName(rv.DefaultAnimationFile())
+	name, ok := "", false
+	if dialog.Run() == gtk.RESPONSE_ACCEPT {
+		name, ok = dialog.GetFilename(), true
+	}
+	dialog.Destroy()
+	if !ok {
+		return
+	}
+	if err := t.Export(name); err != nil {
+		ShowErrorDialog(parent, err)
+	}
+}
@@ -0,0 +1,130 @@
+// Copyright 2020 Howard C. Shaw III. All rights reserved.
+// Use of this source code is governed by the MIT-license
+// as defined in the LICENSE file.
+
+// +build !gotk3,!nogtk2 !shiny,!nogtk2
+
+package gtk2
+
+import (
+	"log"
+
+	rv "github.com/TheGrum/renderview"
+
+	"github.com/mattn/go-gtk/glib"
+	"github.com/mattn/go-gtk/gtk"
+)
+
+// BookmarkPanel is the sidebar's presets list. Choosing a bookmark jumps
+// to it; the entry and buttons add the current view or remove the chosen one.
+type BookmarkPanel struct {
+	*gtk.VBox
+
+	B *rv.Bookmarks
+
+	combo      *gtk.ComboBoxText
+	entry      *gtk.Entry
+	count      int
+	refreshing bool
+	jumping    bool
+}
+
+// NewBookmarkPanel loads the model's bookmarks and builds a panel for them
+func NewBookmarkPanel(w *GtkRenderWidget) *BookmarkPanel {
+	b := &BookmarkPanel{
+		VBox:  gtk.NewVBox(false, 1),
+		B:     w.R.GetBookmarks(),
+		combo: gtk.NewComboBoxText(),
+		entry: gtk.NewEntry(),
+	}
+	if err := b.B.Load(); err != nil {
+		log.Printf("renderview: loading bookmarks: %v", err)
+	}
+	b.refresh()
+	b.B.OnChange(b.refresh)
+
+	goButton := gtk.NewButtonWithLabel("Go")
+	removeButton := gtk.NewButtonWithLabel("Remove")
+	addButton := gtk.NewButtonWithLabel("Add")
+	b.entry.SetTooltipText("Name for a bookmark of the current view")
+
+	b.combo.Connect("changed", func() {
+		if !b.refreshing {
+			b.jump(w)
+		}
+	})
+	goButton.Clicked(func() {
+		b.jump(w)
+	})
+	removeButton.Clicked(func() {
+		if name := b.combo.GetActiveText(); name != "" {
+			if err := b.B.Remove(name); err != nil {
+				ShowErrorDialog(nil, err)
+			}
+		}
+	})
+	add := func() {
+		if err := b.B.Add(b.entry.GetText()); err != nil {
+			ShowErrorDialog(nil, err)
+			return
+		}
+		b.entry.SetText("")
+	}
+	addButton.Clicked(add)
+	b.entry.Connect("activate", add)
+
+	row := gtk.NewHBox(true, 1)
+	row.PackStart(goButton, true, true, 0)
+	row.PackStart(removeButton, true, true, 0)
+	entryRow := gtk.NewHBox(false, 1)
+	entryRow.PackStart(b.entry, true, true, 0)
+	entryRow.PackStart(addButton, false, false, 0)
+
+	b.PackStart(b.combo, false, false, 1)
+	b.PackStart(row, false, false, 1)
+	b.PackStart(entryRow, false, false, 1)
+	return b
+}
+
+// refresh fills the list from the bookmarks, keeping the chosen one
+func (b *BookmarkPanel) refresh() {
+	b.refreshing = true
+	defer func() { b.refreshing = false }()
+	active := b.combo.GetActiveText()
+	for ; b.count > 0; b.count-- {
+		b.combo.Remove(0)
+	}
+	for i, name := range b.B.Names() {
+		b.combo.AppendText(name)
+		if name == active {
+			b.combo.SetActive(i)
+		}
+		b.count++
+	}
+}
+
+// jump starts moving to the chosen bookmark, stepping the animation from
+// a timer until it arrives
+func (b *BookmarkPanel) jump(w *GtkRenderWidget) {
+	name := b.combo.GetActiveText()
+	if name == "" {
+		return
+	}
+	if err := b.B.Jump(name); err != nil {
+		ShowErrorDialog(nil, err)
+		return
+	}
+	w.SetNeedsPaint()
+	if b.jumping {
+		return
+	}
+	b.jumping = true
+	glib.TimeoutAdd(30, func() bool {
+		if !b.B.Step() {
+			b.jumping = false
+			return false
+		}
+		w.SetNeedsPaint()
+		return true
+	})
+}
@@ -0,0 +1,116 @@
+// Copyright 2020 Howard C. Shaw III. All rights reserved.
+// Use of this source code is governed by the MIT-license
+// as defined in the LICENSE file.
+
+// +build !gotk3,!nogtk2 !shiny,!nogtk2
+
+package gtk2
+
+import (
+	"log"
+	"strings"
+
+	rv "github.com/TheGrum/renderview"
+
+	"github.com/mattn/go-gtk/gtk"
+)
+
+func addParameterFileFilters(fc *gtk.FileChooser) {
+	filter := gtk.NewFileFilter()
+	filter.SetName("Parameter files")
+	filter.AddPattern("*.json")
+	fc.AddFilter(filter)
+	filter = gtk.NewFileFilter()
+	filter.SetName("All files")
+	filter.AddPattern("*")
+	fc.AddFilter(filter)
+}
+
+// SaveParametersDialog asks for a file name and saves the model's parameters to it
+func SaveParametersDialog(parent *gtk.Window, r rv.RenderModel) {
+	dialog := gtk.NewFileChooserDialog("Save Parameters", parent, gtk.FILE_CHOOSER_ACTION_SAVE,
+		gtk.STOCK_CANCEL, gtk.RESPONSE_CANCEL, gtk.STOCK_SAVE, gtk.RESPONSE_ACCEPT)
+	dialog.SetDoOverwriteConfirmation(true)
+	dialog.SetCurrentName(rv.DefaultParameterFile())
+	addParameterFileFilters(&dialog.FileChooser)
+	if dialog.Run() == gtk.RESPONSE_ACCEPT {
+		if err := rv.SaveParametersToFile(dialog.GetFilename(), r); err != nil {
+			ShowErrorDialog(parent, err)
+		}
+	}
+	dialog.Destroy()
+}
+
+// OpenParametersDialog asks for a file and loads the model's parameters
+// from it, reporting whether anything was loaded
+func OpenParametersDialog(parent *gtk.Window, r rv.RenderModel) bool {
+	dialog := gtk.NewFileChooserDialog("Open Parameters", parent, gtk.FILE_CHOOSER_ACTION_OPEN,
+		gtk.STOCK_CANCEL, gtk.RESPONSE_CANCEL, gtk.STOCK_OPEN, gtk.RESPONSE_ACCEPT)
+	addParameterFileFilters(&dialog.FileChooser)
+	loaded := false
+	if dialog.Run() == gtk.RESPONSE_ACCEPT {
+		loaded = true
+		if err := rv.LoadParametersFromFile(dialog.GetFilename(), r); err != nil {
+			ShowErrorDialog(parent, err)
+		}
+	}
+	dialog.Destroy()
+	return loaded
+}
+
+// ChoosePathDialog asks for a path for the path parameter p with the
+// chooser matching its mode, starting from its current value, and reports
+// whether one was chosen
+func ChoosePathDialog(parent *gtk.Window, p rv.RenderParameter) (string, bool) {
+	mode, patterns := p.GetPathOptions()
+	action, button := gtk.FILE_CHOOSER_ACTION_OPEN, gtk.STOCK_OPEN
+	switch mode {
+	case rv.PATH_SAVE:
+		action, button = gtk.FILE_CHOOSER_ACTION_SAVE, gtk.STOCK_SAVE
+	case rv.PATH_DIRECTORY:
+		action = gtk.FILE_CHOOSER_ACTION_SELECT_FOLDER
+	}
+	dialog := gtk.NewFileChooserDialog(rv.GetParameterLabel(p), parent, action,
+		gtk.STOCK_CANCEL, gtk.RESPONSE_CANCEL, button, gtk.RESPONSE_ACCEPT)
+	dialog.SetDoOverwriteConfirmation(mode == rv.PATH_SAVE)
+	if v := p.GetValueString(); v != "" {
+		dialog.SetFilename(v)
+	}
+	if len(patterns) > 0 {
+		filter := gtk.NewFileFilter()
+		filter.SetName(strings.Join(patterns, ", "))
+		for _, pattern := range patterns {
+			filter.AddPattern(pattern)
+		}
+		dialog.AddFilter(filter)
+		filter = gtk.NewFileFilter()
+		filter.SetName("All files")
+		filter.AddPattern("*")
+		dialog.AddFilter(filter)
+	}
+	path, ok := "", false
+	if dialog.Run() == gtk.RESPONSE_ACCEPT {
+		path, ok = dialog.GetFilename(), true
+	}
+	dialog.Destroy()
+	return path, ok
+}
+
+// ShowErrorDialog reports err in a modal message box
+func ShowErrorDialog(parent *gtk.Window, err error) {
+	dialog := gtk.NewMessageDialog(parent, gtk.DIALOG_MODAL, gtk.MESSAGE_ERROR, gtk.BUTTONS_OK, "%v", err)
+	dialog.Run()
+	dialog.Destroy()
+}
+
+func restoreSession(r rv.RenderModel) {
+	if err := rv.RestoreSession(r); err != nil {
+		log.Printf("renderview: restoring session: %v", err)
+	}
+}
+
+func saveSession(r rv.RenderModel) {
+	if err := rv.SaveSession(r); err != nil {
+		log.Printf("renderview: saving session: %v", err)
+	}
+}
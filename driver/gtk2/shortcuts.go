@@ -0,0 +1,38 @@
+// Copyright 2020 Howard C. Shaw III. All rights reserved.
+// Use of this source code is governed by the MIT-license
+// as defined in the LICENSE file.
+
+// +build !gotk3,!nogtk2 !shiny,!nogtk2
+
+package gtk2
+
+import (
+	"github.com/mattn/go-gtk/gdk"
+	"github.com/mattn/go-gtk/gtk"
+)
+
+// OnWindowKeyPress handles the shortcuts that work anywhere in the window:
+// Ctrl+S saves the parameters to a file and Ctrl+O loads them from one,
+// Ctrl+Z undoes the last change and Ctrl+Shift+Z redoes it
+func (w *GtkRenderWidget) OnWindowKeyPress(window *gtk.Window, e *gdk.EventKey) bool {
+	state := gdk.ModifierType(e.State)
+	if state&gdk.CONTROL_MASK == 0 {
+		return false
+	}
+	switch e.Keyval {
+	case gdk.KEY_s:
+		SaveParametersDialog(window, w.R)
+	case gdk.KEY_o:
+		if OpenParametersDialog(window, w.R) {
+			w.SetNeedsPaint()
+		}
+	case gdk.KEY_z, gdk.KEY_Z:
+		h := w.R.GetHistory()
+		if state&gdk.SHIFT_MASK == 0 && h.Undo() || state&gdk.SHIFT_MASK != 0 && h.Redo() {
+			w.SetNeedsPaint()
+		}
+	default:
+		return false
+	}
+	return true
+}
@@ -8,6 +8,7 @@ package gtk2
 
 import (
 	"image"
+	"image/color"
 	"image/draw"
 	"unsafe"
 
@@ -33,8 +34,11 @@ func Main(m rv.RenderModel) {
 func GtkWindowInit(r rv.RenderModel) {
 	gtk.Init(nil)
 	window := GetGtkWindow(r, false)
+	// the widget has set the paint function that restoring may call
+	restoreSession(r)
 	window.Connect("destroy", func(ctx *glib.CallbackContext) {
 		//		println("got destroy!", ctx.Data().(string))
+		saveSession(r)
 		gtk.MainQuit()
 	}, "foo")
 
@@ -46,8 +50,11 @@ func GtkWindowInit(r rv.RenderModel) {
 func GtkWindowWithWidgetsInit(r rv.RenderModel) {
 	gtk.Init(nil)
 	window := GetGtkWindow(r, true)
+	// the widget has set the paint function that restoring may call
+	restoreSession(r)
 	window.Connect("destroy", func(ctx *glib.CallbackContext) {
 		//		println("got destroy!", ctx.Data().(string))
+		saveSession(r)
 		gtk.MainQuit()
 	}, "foo")
 
@@ -65,36 +72,125 @@ func GetGtkWindow(r rv.RenderModel, addWidgets bool) *gtk.Window {
 		child = WrapRenderWidget(render)
 	}
 	window.Add(child)
+	window.Connect("key-press-event", func(ctx *glib.CallbackContext) bool {
+		arg := ctx.Args(0)
+		kev := *(**gdk.EventKey)(unsafe.Pointer(&arg))
+		return render.OnWindowKeyPress(window, kev)
+	})
 	return window
 }
 
 func WrapRenderWidget(r *GtkRenderWidget) gtk.IWidget {
 	parent := gtk.NewHBox(false, 1)
 	sidebar := gtk.NewVBox(false, 1)
-	names := r.R.GetHintedParameterNamesWithFallback(rv.HINT_SIDEBAR | rv.HINT_FOOTER)
-	if len(names) > 0 {
-		sidebar.PackStart(gtk.NewLabel("________Parameters________"), false, false, 1)
-
-		for i := 0; i < len(names); i++ {
-			sidebar.PackStart(gtk.NewLabel(names[i]), false, false, 1)
-			tv := NewGtkParamWidget(r.R.GetParameter(names[i]), r)
+	// the bar stays in place when idle, so a render starting does not
+	// move the sidebar
+	r.Progress = gtk.NewProgressBar()
+	sidebar.PackStart(r.Progress, false, false, 1)
+	for _, g := range r.R.GetParameterGroups(rv.HINT_SIDEBAR) {
+		box := gtk.NewVBox(false, 1)
+		for _, name := range g.Params {
+			p := r.R.GetParameter(name)
+			if !hasOwnLabel(p) {
+				// checkboxes carry their own label
+				label := gtk.NewLabel(rv.GetParameterLabel(p))
+				label.SetTooltipText(p.GetDescription())
+				box.PackStart(label, false, false, 1)
+			}
+			tv := NewGtkParamWidget(p, r)
 			r.ParamWidgets = append(r.ParamWidgets, tv)
-			sidebar.PackStart(tv, false, false, 1)
+			box.PackStart(withUnit(tv, p), false, false, 1)
+			if tv.Message != nil {
+				box.PackStart(tv.Message, false, false, 1)
+			}
 		}
-
-		parent.PackStart(sidebar, false, true, 0)
+		sidebar.PackStart(newSection(g.Title(), box), false, false, 1)
 	}
-	names = r.R.GetHintedParameterNames(rv.HINT_FULLTEXT)
+	sidebar.PackStart(newSection("Bookmarks", NewBookmarkPanel(r)), false, false, 1)
+	sidebar.PackStart(newSection("Animation", NewAnimationPanel(r)), false, false, 1)
+	parent.PackStart(sidebar, false, true, 0)
+	names := r.R.GetHintedParameterNames(rv.HINT_FULLTEXT)
 	if len(names) > 0 {
 		// we can only do this for one parameter, so ignore multiples
 		tv := NewGtkParamWidget(r.R.GetParameter(names[0]), r)
 		r.ParamWidgets = append(r.ParamWidgets, tv)
-		parent.PackStart(tv, true, true, 1)
+		box := gtk.NewVBox(false, 1)
+		box.PackStart(tv, true, true, 0)
+		if tv.Message != nil {
+			box.PackStart(tv.Message, false, false, 0)
+		}
+		parent.PackStart(box, true, true, 1)
+	}
+	names = r.R.GetHintedParameterNames(rv.HINT_FOOTER)
+	if len(names) > 0 {
+		// the footer takes its height from the drawing area, so the
+		// width and height parameters report what is left for the image
+		box := gtk.NewVBox(false, 1)
+		box.PackStart(r, true, true, 0)
+		box.PackStart(NewFooter(r, names), false, false, 1)
+		parent.PackEnd(box, true, true, 2)
+	} else {
+		parent.PackEnd(r, true, true, 2)
 	}
-	parent.PackEnd(r, true, true, 2)
 	return parent
 }
 
+// NewFooter lays out the named parameters in a row of label and editor pairs
+func NewFooter(r *GtkRenderWidget, names []string) gtk.IWidget {
+	footer := gtk.NewHBox(false, 6)
+	for _, name := range names {
+		p := r.R.GetParameter(name)
+		tv := NewGtkParamWidget(p, r)
+		r.ParamWidgets = append(r.ParamWidgets, tv)
+		pair := gtk.NewHBox(false, 2)
+		if !hasOwnLabel(p) {
+			label := gtk.NewLabel(rv.GetParameterLabel(p))
+			label.SetTooltipText(p.GetDescription())
+			pair.PackStart(label, false, false, 0)
+		}
+		if t, ok := tv.IWidget.(*gtk.TextView); ok {
+			t.SetSizeRequest(FOOTER_TEXT_WIDTH, -1)
+		}
+		pair.PackStart(withUnit(tv, p), true, true, 0)
+		cell := gtk.NewVBox(false, 0)
+		cell.PackStart(pair, false, false, 0)
+		if tv.Message != nil {
+			cell.PackStart(tv.Message, false, false, 0)
+		}
+		footer.PackStart(cell, false, false, 0)
+	}
+	return footer
+}
+
+// FOOTER_TEXT_WIDTH is the width in pixels of text editors in the footer
+const FOOTER_TEXT_WIDTH = 80
+
+// hasOwnLabel reports whether the widget for p shows its label itself,
+// as checkboxes do
+func hasOwnLabel(p rv.RenderParameter) bool {
+	return p.GetType() == "bool" && !rv.IsReadOnly(p)
+}
+
+// withUnit packs w beside a label showing the unit of p, if it has one
+func withUnit(w gtk.IWidget, p rv.RenderParameter) gtk.IWidget {
+	u := p.GetMeta(rv.META_UNIT)
+	if u == "" {
+		return w
+	}
+	box := gtk.NewHBox(false, 2)
+	box.PackStart(w, true, true, 0)
+	box.PackStart(gtk.NewLabel(u), false, false, 0)
+	return box
+}
+
+// newSection wraps child in an expanded Expander headed by title
+func newSection(title string, child gtk.IWidget) *gtk.Expander {
+	e := gtk.NewExpander(title)
+	e.Add(child)
+	e.SetExpanded(true)
+	return e
+}
+
 type GtkRenderWidget struct {
 	*gtk.DrawingArea
 
@@ -104,30 +200,30 @@ type GtkRenderWidget struct {
 	index int
 	R     rv.RenderModel
 
-	left,
-	top,
-	right,
-	bottom,
 	width,
 	height,
-	zoom,
 	mouseX,
 	mouseY,
-	options,
-	page,
-	zoomRate rv.RenderParameter
+	page rv.RenderParameter
+
+	// view pans and zooms the model's viewport parameters
+	view *rv.Viewport
 
 	sx,
 	sy float64
 
-	leftIsFloat64,
-	zoomIsFloat64,
 	mouseIsDown,
 	dragging,
 	needsPaint bool
+	// needsUpdate is set when a parameter widget is dirty. Like the
+	// widgets' dirty flags it is guarded by the model lock, which is held
+	// whenever a parameter changes.
 	needsUpdate bool
 
 	ParamWidgets []*GtkParamWidget
+	// Progress shows how far a render has got, when there is a sidebar
+	Progress         *gtk.ProgressBar
+	watchingProgress bool
 }
 
 func NewGtkRenderWidget(r rv.RenderModel) *GtkRenderWidget {
@@ -135,20 +231,12 @@ func NewGtkRenderWidget(r rv.RenderModel) *GtkRenderWidget {
 		DrawingArea: gtk.NewDrawingArea(),
 		R:           r,
 	}
-	w.left = r.GetParameter("left")
-	w.top = r.GetParameter("top")
-	w.right = r.GetParameter("right")
-	w.bottom = r.GetParameter("bottom")
 	w.width = r.GetParameter("width")
 	w.height = r.GetParameter("height")
-	w.zoom = r.GetParameter("zoom")
 	w.mouseX = r.GetParameter("mouseX")
 	w.mouseY = r.GetParameter("mouseY")
-	w.options = r.GetParameter("options")
 	w.page = r.GetParameter("page")
-	w.zoomRate = r.GetParameter("zoomRate")
-	w.leftIsFloat64 = w.left.GetType() == "float64"
-	w.zoomIsFloat64 = w.zoom.GetType() == "float64"
+	w.view = rv.NewViewport(r)
 	w.Connect("expose-event", w.Draw)
 	w.Connect("configure-event", w.Configure)
 	w.Connect("motion-notify-event", func(ctx *glib.CallbackContext) {
@@ -178,21 +266,17 @@ func NewGtkRenderWidget(r rv.RenderModel) *GtkRenderWidget {
 	})
 	w.R.SetRequestPaintFunc(func() {
 		//w.UpdateParamWidgets()
-		w.needsUpdate = true
-		w.needsPaint = true
-		//w.QueueDraw()
-		//w.GetWindow().Invalidate(nil, false)
+		// the renderer may call this from its own goroutine
+		glib.IdleAdd(w.refreshOutputs)
+		glib.IdleAdd(func() bool {
+			w.needsPaint = true
+			w.QueueDraw()
+			return false
+		})
 	})
 	w.SetCanFocus(true)
 	//	w.SetFocusOnClick(true) // missing?
 	w.SetEvents(int(gdk.POINTER_MOTION_MASK | gdk.BUTTON_PRESS_MASK | gdk.BUTTON_RELEASE_MASK | gdk.EXPOSURE_MASK | gdk.SCROLL_MASK | gdk.KEY_PRESS_MASK))
-	// This doesn't seem to actually work?
-	glib.TimeoutAdd(3000, func() int {
-		if w.needsPaint {
-			w.QueueDraw()
-		}
-		return 1
-	})
 
 	return w
 }
@@ -202,13 +286,65 @@ func (w *GtkRenderWidget) SetNeedsPaint() {
 	w.QueueDraw()
 }
 
+// UpdateParamWidgets refreshes the widgets whose parameters have changed
+// behind their backs
 func (w *GtkRenderWidget) UpdateParamWidgets() {
 	w.R.Lock()
 	defer w.R.Unlock()
-	for i := 0; i < len(w.ParamWidgets); i++ {
-		w.ParamWidgets[i].Update()
+	if !w.needsUpdate {
+		return
 	}
 	w.needsUpdate = false
+	for i := 0; i < len(w.ParamWidgets); i++ {
+		if w.ParamWidgets[i].dirty {
+			w.ParamWidgets[i].dirty = false
+			w.ParamWidgets[i].Update()
+		}
+	}
+}
+
+// refreshOutputs updates the widgets of read-only parameters from the
+// main loop after the model requests a paint
+func (w *GtkRenderWidget) refreshOutputs() bool {
+	w.R.Lock()
+	for _, pw := range w.ParamWidgets {
+		if rv.IsReadOnly(pw.P) {
+			pw.dirty = true
+			w.needsUpdate = true
+		}
+	}
+	w.R.Unlock()
+	w.UpdateParamWidgets()
+	w.refreshProgress()
+	return false
+}
+
+// refreshProgress shows the progress of the render, updating it from a
+// timer while the render runs so the time left keeps counting down
+func (w *GtkRenderWidget) refreshProgress() {
+	if w.Progress == nil {
+		return
+	}
+	if w.showProgress() && !w.watchingProgress {
+		w.watchingProgress = true
+		glib.TimeoutAdd(250, func() bool {
+			w.watchingProgress = w.showProgress()
+			return w.watchingProgress
+		})
+	}
+}
+
+// showProgress sets the progress bar from the model, reporting whether a
+// render is running
+func (w *GtkRenderWidget) showProgress() bool {
+	p := rv.GetProgress(w.R)
+	if p.Reported() {
+		w.Progress.SetFraction(p.Fraction)
+	} else {
+		w.Progress.SetFraction(0)
+	}
+	w.Progress.SetText(p.String())
+	return p.Active
 }
 
 func (w *GtkRenderWidget) Configure() {
@@ -217,18 +353,17 @@ func (w *GtkRenderWidget) Configure() {
 		w.pixbuf.Unref()
 	}
 	allocation := w.GetAllocation()
+	w.R.Lock()
 	w.width.SetValueInt(allocation.Width)
 	w.height.SetValueInt(allocation.Height)
+	w.R.Unlock()
 
 	w.pixbuf = gdkpixbuf.NewPixbuf(gdkpixbuf.GDK_COLORSPACE_RGB, true, 8, allocation.Width, allocation.Height)
 	w.needsPaint = true
-	w.needsUpdate = true
 }
 
 func (w *GtkRenderWidget) Draw(ctx *glib.CallbackContext) {
-	if w.needsUpdate {
-		w.UpdateParamWidgets()
-	}
+	w.UpdateParamWidgets()
 	if w.needsPaint || w.Image == nil {
 		img := w.R.Render()
 		if img == nil {
@@ -262,6 +397,22 @@ func (w *GtkRenderWidget) Draw(ctx *glib.CallbackContext) {
 	draw := win.GetDrawable()
 	gc := gdk.NewGC(draw)
 	draw.DrawPixbuf(gc, w.pixbuf, 0, 0, 0, 0, w.pixbuf.GetWidth(), w.pixbuf.GetHeight(), gdk.RGB_DITHER_NONE, 0, 0)
+
+	// mark the picked points
+	w.R.Lock()
+	markers := rv.PickMarkers(w.R)
+	w.R.Unlock()
+	for _, pt := range markers {
+		outlines, arms := rv.CrosshairRects(pt)
+		gc.SetRgbFgColor(gdk.NewColor("black"))
+		for _, r := range outlines {
+			draw.DrawRectangle(gc, true, r.Min.X, r.Min.Y, r.Dx(), r.Dy())
+		}
+		gc.SetRgbFgColor(gdk.NewColor("white"))
+		for _, r := range arms {
+			draw.DrawRectangle(gc, true, r.Min.X, r.Min.Y, r.Dx(), r.Dy())
+		}
+	}
 }
 
 func GdkPixelCopy(source *image.RGBA, target *gdkpixbuf.Pixbuf, region image.Rectangle, targetOffset image.Point) {
@@ -331,79 +482,23 @@ func GdkPixelCopyNRGBA(source *image.NRGBA, target *gdkpixbuf.Pixbuf, region ima
 }
 
 func (w *GtkRenderWidget) OnScroll(e *gdk.EventScroll) {
+	w.R.GetHistory().Checkpoint("zoom")
+	w.R.Lock()
 	// the case of SCROLL_Down is incorrect in gdk.go
 	// todo: fix this when it is fixed upstream
 	// e.Direction always has same value, and does not match documentation
-	if gdk.ModifierType(e.State) > (1 << 30) {
-		if w.zoomIsFloat64 {
-			w.zoom.SetValueFloat64(w.zoom.GetValueFloat64() - 1)
-		} else {
-			w.zoom.SetValueInt(w.zoom.GetValueInt() - 1)
-		}
-		if w.options.GetValueInt()&rv.OPT_AUTO_ZOOM == rv.OPT_AUTO_ZOOM || w.options.GetValueInt()&rv.OPT_CENTER_ZOOM == rv.OPT_CENTER_ZOOM {
-			mult := 1 + rv.ZOOM_RATE
-			if w.zoomRate.GetValueFloat64() > 0 {
-				mult = 1 + w.zoomRate.GetValueFloat64()
-			}
-			if w.leftIsFloat64 {
-
-				zwidth := w.right.GetValueFloat64() - w.left.GetValueFloat64()
-				zheight := w.bottom.GetValueFloat64() - w.top.GetValueFloat64()
-				nzwidth := zwidth * mult
-				nzheight := zheight * mult
-				cx := float64(e.X) / float64(w.width.GetValueInt())
-				cy := float64(e.Y) / float64(w.height.GetValueInt())
-				if w.options.GetValueInt()&rv.OPT_CENTER_ZOOM == rv.OPT_CENTER_ZOOM {
-					cx = 0.5
-					cy = 0.5
-				}
-				//fmt.Printf("zoomOut: mult: %v zwidth: %v nzwidth: %v cx: %v left: %v nleft: %v\n", mult, zwidth, nzwidth, cx, left.GetValueFloat64(), left.GetValueFloat64()-((nzwidth-zwidth)*cx))
-				w.left.SetValueFloat64(w.left.GetValueFloat64() - ((nzwidth - zwidth) * cx))
-				w.top.SetValueFloat64(w.top.GetValueFloat64() - ((nzheight - zheight) * cy))
-				w.right.SetValueFloat64(w.left.GetValueFloat64() + nzwidth)
-				w.bottom.SetValueFloat64(w.top.GetValueFloat64() + nzheight)
-			}
-		}
-
-		//if gdk.ScrollDirection(e.Direction) == gdk.SCROLL_UP {
-	} else {
-		if w.zoomIsFloat64 {
-			w.zoom.SetValueFloat64(w.zoom.GetValueFloat64() + 1)
-		} else {
-			w.zoom.SetValueInt(w.zoom.GetValueInt() + 1)
-		}
-		if w.options.GetValueInt()&rv.OPT_AUTO_ZOOM == rv.OPT_AUTO_ZOOM || w.options.GetValueInt()&rv.OPT_CENTER_ZOOM == rv.OPT_CENTER_ZOOM {
-			mult := 1 - rv.ZOOM_RATE
-			if w.zoomRate.GetValueFloat64() > 0 {
-				mult = 1 - w.zoomRate.GetValueFloat64()
-			}
-			if w.leftIsFloat64 {
-				zwidth := w.right.GetValueFloat64() - w.left.GetValueFloat64()
-				zheight := w.bottom.GetValueFloat64() - w.top.GetValueFloat64()
-				nzwidth := zwidth * mult
-				nzheight := zheight * mult
-				cx := float64(e.X) / float64(w.width.GetValueInt())
-				cy := float64(e.Y) / float64(w.height.GetValueInt())
-				if w.options.GetValueInt()&rv.OPT_CENTER_ZOOM == rv.OPT_CENTER_ZOOM {
-					cx = 0.5
-					cy = 0.5
-				}
-				w.left.SetValueFloat64(w.left.GetValueFloat64() - ((nzwidth - zwidth) * cx))
-				w.top.SetValueFloat64(w.top.GetValueFloat64() - ((nzheight - zheight) * cy))
-				w.right.SetValueFloat64(w.left.GetValueFloat64() + nzwidth)
-				w.bottom.SetValueFloat64(w.top.GetValueFloat64() + nzheight)
-			}
-		}
-	}
-	w.needsUpdate = true
+	w.view.ZoomAt(float64(e.X), float64(e.Y), gdk.ModifierType(e.State) > (1 << 30))
+	w.R.Unlock()
 	w.SetNeedsPaint()
 
 }
 
 func (w *GtkRenderWidget) OnMotion(e *gdk.EventMotion) {
 	//	fmt.Printf("Motion: X:%v Y:%v sx:%v sy:%v mouseIsDown:%v dragging:%v\n", e.X, e.Y, w.sx, w.sy, w.mouseIsDown, w.dragging)
+	w.R.Lock()
 	w.mouseX.SetValueFloat64(float64(e.X))
 	w.mouseY.SetValueFloat64(float64(e.Y))
+	w.R.Unlock()
 
 	if w.needsPaint {
 		w.QueueDraw()
@@ -416,32 +511,11 @@ func (w *GtkRenderWidget) OnMotion(e *gdk.EventMotion) {
 	}
 
 	if w.dragging {
-		if w.leftIsFloat64 {
-			width := w.right.GetValueFloat64() - w.left.GetValueFloat64()
-			height := w.bottom.GetValueFloat64() - w.top.GetValueFloat64()
-			dx := width / float64(w.width.GetValueInt())
-			dy := height / float64(w.height.GetValueInt())
-			cx := float64(e.X-w.sx) * dx
-			cy := float64(e.Y-w.sy) * dy
-			//						fmt.Printf("dx %v dy %v cx %v cy %v\n", dx, dy, cx, cy)
-			w.left.SetValueFloat64(w.left.GetValueFloat64() - cx)
-			w.right.SetValueFloat64(w.right.GetValueFloat64() - cx)
-			w.top.SetValueFloat64(w.top.GetValueFloat64() - cy)
-			w.bottom.SetValueFloat64(w.bottom.GetValueFloat64() - cy)
-		} else {
-			width := w.right.GetValueInt() - w.left.GetValueInt()
-			height := w.bottom.GetValueInt() - w.top.GetValueInt()
-			dx := float64(width) / float64(w.width.GetValueInt())
-			dy := float64(height) / float64(w.height.GetValueInt())
-			cx := float64(e.X-w.sx) * dx
-			cy := float64(e.Y-w.sy) * dy
-			w.left.SetValueInt(int(float64(w.left.GetValueInt()) - cx))
-			w.right.SetValueInt(int(float64(w.right.GetValueInt()) - cx))
-			w.top.SetValueInt(int(float64(w.top.GetValueInt()) - cy))
-			w.bottom.SetValueInt(int(float64(w.bottom.GetValueInt()) - cy))
-		}
+		w.R.GetHistory().Checkpoint("pan")
+		w.R.Lock()
+		w.view.Pan(e.X-w.sx, e.Y-w.sy)
+		w.R.Unlock()
 		//			Draw(r.Render(), buf.RGBA())
-		w.needsUpdate = true
 		w.SetNeedsPaint()
 
 		w.sx = e.X
@@ -453,8 +527,10 @@ func (w *GtkRenderWidget) OnMotion(e *gdk.EventMotion) {
 
 func (w *GtkRenderWidget) OnButton(e *gdk.EventButton) {
 	//	fmt.Printf("Button called with %v\n", e)
+	w.R.Lock()
 	w.mouseX.SetValueFloat64(float64(e.X))
 	w.mouseY.SetValueFloat64(float64(e.Y))
+	w.R.Unlock()
 	w.GrabFocus()
 
 	if gdk.EventType(e.Type) == gdk.BUTTON_PRESS {
@@ -467,6 +543,10 @@ func (w *GtkRenderWidget) OnButton(e *gdk.EventButton) {
 	}
 	if gdk.EventType(e.Type) == gdk.BUTTON_RELEASE {
 		//		fmt.Println("Mouseup")
+		// a click, rather than the end of a drag, picks a point
+		if w.mouseIsDown && !w.dragging && rv.PickAt(w.R, e.X, e.Y) {
+			w.SetNeedsPaint()
+		}
 		w.mouseIsDown = false
 		w.dragging = false
 	}
@@ -474,13 +554,17 @@ func (w *GtkRenderWidget) OnButton(e *gdk.EventButton) {
 
 func (w *GtkRenderWidget) OnKeyPress(e *gdk.EventKey) {
 	if e.Keyval == gdk.KEY_Page_Up {
+		w.R.GetHistory().Checkpoint("page")
+		w.R.Lock()
 		w.page.SetValueInt(w.page.GetValueInt() - 1)
-		w.needsUpdate = true
+		w.R.Unlock()
 		w.SetNeedsPaint()
 	}
 	if e.Keyval == gdk.KEY_Page_Down {
+		w.R.GetHistory().Checkpoint("page")
+		w.R.Lock()
 		w.page.SetValueInt(w.page.GetValueInt() + 1)
-		w.needsUpdate = true
+		w.R.Unlock()
 		w.SetNeedsPaint()
 	}
 }
@@ -488,35 +572,415 @@ func (w *GtkRenderWidget) OnKeyPress(e *gdk.EventKey) {
 type GtkParamWidget struct {
 	gtk.IWidget
 	P rv.RenderParameter
+
+	// Message, if present, is shown below the widget when input is rejected
+	Message *gtk.Label
+	base    *gdk.Color
+
+	// dirty is set when the parameter changes behind the widget's back,
+	// editing while the widget itself is writing the parameter, and
+	// updating while Update is writing the widget
+	dirty    bool
+	editing  bool
+	updating bool
+	model    rv.RenderModel
+	history  *rv.History
 }
 
 func NewGtkParamWidget(p rv.RenderParameter, w *GtkRenderWidget) *GtkParamWidget {
+	var r *GtkParamWidget
+	if rv.IsReadOnly(p) {
+		r = NewGtkOutputParamWidget(p)
+	} else {
+		switch p.GetType() {
+		case "bool":
+			r = NewGtkCheckParamWidget(p, w)
+		case "choice":
+			r = NewGtkComboParamWidget(p, w)
+		case "path":
+			r = NewGtkPathParamWidget(p, w)
+		case "[]int", "[]float64", "[]string":
+			r = NewGtkListParamWidget(p, w)
+		case "color":
+			r = NewGtkColorParamWidget(p, w)
+		case "int", "float64":
+			if _, _, _, bounded := p.GetRange(); bounded {
+				r = NewGtkScaleParamWidget(p, w)
+			} else {
+				r = NewGtkTextParamWidget(p, w)
+			}
+		default:
+			r = NewGtkTextParamWidget(p, w)
+		}
+	}
+	r.model = w.R
+	r.history = w.R.GetHistory()
+	if d := p.GetDescription(); d != "" {
+		if t, ok := r.IWidget.(interface{ SetTooltipText(string) }); ok {
+			t.SetTooltipText(d)
+		}
+	}
+	p.OnChange(func(oldValue interface{}, newValue interface{}) {
+		if !r.editing {
+			r.dirty = true
+			w.needsUpdate = true
+		}
+	})
+	return r
+}
+
+// set runs f, which writes the parameter from the widget, under the
+// model lock and without marking the widget for refresh, recording the
+// change in the history. The widget's own signals during Update are ignored.
+func (r *GtkParamWidget) set(f func()) {
+	if r.updating {
+		return
+	}
+	if r.history != nil {
+		r.history.Checkpoint(r.P.GetName())
+	}
+	if r.model != nil {
+		r.model.Lock()
+		defer r.model.Unlock()
+	}
+	r.editing = true
+	f()
+	r.editing = false
+}
+
+// NewGtkTextParamWidget edits the string form of any parameter in a TextView
+func NewGtkTextParamWidget(p rv.RenderParameter, w *GtkRenderWidget) *GtkParamWidget {
 	tv := gtk.NewTextView()
 	r := &GtkParamWidget{
 		IWidget: tv,
 		P:       p,
+		Message: gtk.NewLabel(""),
+	}
+	r.Message.SetNoShowAll(true)
+	r.Message.SetLineWrap(true)
+	r.Message.ModifyFG(gtk.STATE_NORMAL, gdk.NewColor("#c00000"))
+	if c, ok := tv.GetStyle().LookupColor("base_color"); ok {
+		r.base = c
+	} else {
+		r.base = gdk.NewColor("white")
 	}
 	tv.SetEditable(true)
 	tv.SetCursorVisible(true)
 	tb := tv.GetBuffer()
-	tb.SetText(rv.GetParameterValueAsString(p))
+	tb.SetText(rv.FormatParameterValue(p))
 	tb.Connect("changed", func() {
 		var start, end gtk.TextIter
 		tb.GetBounds(&start, &end)
 		s := tb.GetText(&start, &end, false)
-		pValue := rv.GetParameterValueAsString(r.P)
+		pValue := rv.FormatParameterValue(r.P)
+		var err error
 		if s != pValue {
-			rv.SetParameterValueFromString(r.P, s)
+			r.set(func() { err = rv.SetParameterValueFromString(r.P, s) })
+		}
+		r.ShowError(err)
+		w.SetNeedsPaint()
+	})
+	return r
+}
+
+// NewGtkOutputParamWidget shows a read-only parameter in a Label, which
+// is refreshed whenever the model requests a paint
+func NewGtkOutputParamWidget(p rv.RenderParameter) *GtkParamWidget {
+	l := gtk.NewLabel(rv.FormatParameterValue(p))
+	l.SetAlignment(0, 0.5)
+	l.SetSelectable(true)
+	return &GtkParamWidget{
+		IWidget: l,
+		P:       p,
+	}
+}
+
+// NewGtkCheckParamWidget edits a bool parameter with a CheckButton
+func NewGtkCheckParamWidget(p rv.RenderParameter, w *GtkRenderWidget) *GtkParamWidget {
+	cb := gtk.NewCheckButtonWithLabel(rv.GetParameterLabel(p))
+	r := &GtkParamWidget{
+		IWidget: cb,
+		P:       p,
+	}
+	cb.SetActive(p.GetValueBool())
+	cb.Connect("toggled", func() {
+		if cb.GetActive() != r.P.GetValueBool() {
+			r.set(func() { r.P.SetValueBool(cb.GetActive()) })
+			w.SetNeedsPaint()
+		}
+	})
+	return r
+}
+
+// NewGtkComboParamWidget edits a choice parameter with a ComboBox
+// listing the allowed values
+func NewGtkComboParamWidget(p rv.RenderParameter, w *GtkRenderWidget) *GtkParamWidget {
+	cb := gtk.NewComboBoxText()
+	r := &GtkParamWidget{
+		IWidget: cb,
+		P:       p,
+	}
+	for _, c := range p.GetChoices() {
+		cb.AppendText(c)
+	}
+	r.Update()
+	cb.Connect("changed", func() {
+		s := cb.GetActiveText()
+		if s != "" && s != r.P.GetValueString() {
+			r.set(func() { r.P.SetValueString(s) })
+			w.SetNeedsPaint()
+		}
+	})
+	return r
+}
+
+// GtkPathChooser is an Entry for a path beside a button opening a file chooser
+type GtkPathChooser struct {
+	*gtk.HBox
+	Entry  *gtk.Entry
+	Browse *gtk.Button
+}
+
+// NewGtkPathParamWidget edits a path parameter with a GtkPathChooser. A
+// typed path is applied when Enter is pressed or the entry loses focus.
+func NewGtkPathParamWidget(p rv.RenderParameter, w *GtkRenderWidget) *GtkParamWidget {
+	pc := &GtkPathChooser{
+		HBox:   gtk.NewHBox(false, 1),
+		Entry:  gtk.NewEntry(),
+		Browse: gtk.NewButtonWithLabel("Browse…"),
+	}
+	pc.PackStart(pc.Entry, true, true, 0)
+	pc.PackStart(pc.Browse, false, false, 0)
+	r := &GtkParamWidget{
+		IWidget: pc,
+		P:       p,
+	}
+	r.Update()
+	apply := func(s string) {
+		if s == r.P.GetValueString() {
+			return
+		}
+		var err error
+		r.set(func() { err = rv.SetParameterValueFromString(r.P, s) })
+		if err != nil {
+			ShowErrorDialog(w.GetTopLevelAsWindow(), err)
+			r.Update()
+			return
+		}
+		w.SetNeedsPaint()
+	}
+	pc.Entry.Connect("activate", func() {
+		apply(pc.Entry.GetText())
+	})
+	pc.Entry.Connect("focus-out-event", func() {
+		apply(pc.Entry.GetText())
+	})
+	pc.Browse.Clicked(func() {
+		if path, ok := ChoosePathDialog(w.GetTopLevelAsWindow(), r.P); ok {
+			pc.Entry.SetText(path)
+			apply(path)
+		}
+	})
+	return r
+}
+
+// GtkListEditor is a row per item of a list, an Entry with buttons to
+// move the item up or down or remove it, above a button adding an item
+type GtkListEditor struct {
+	*gtk.VBox
+	Add     *gtk.Button
+	Entries []*gtk.Entry
+	rows    []*gtk.HBox
+	apply   func(items []string)
+}
+
+// Items returns the text of the entries
+func (le *GtkListEditor) Items() []string {
+	items := make([]string, len(le.Entries))
+	for i, e := range le.Entries {
+		items[i] = e.GetText()
+	}
+	return items
+}
+
+// SetItems replaces the rows with one per item
+func (le *GtkListEditor) SetItems(items []string) {
+	for _, row := range le.rows {
+		le.Remove(row)
+	}
+	le.rows = nil
+	le.Entries = nil
+	button := func(label string, f func(l []string) []string) *gtk.Button {
+		b := gtk.NewButtonWithLabel(label)
+		// keep the entries' focus-out from rebuilding the rows mid-click
+		b.SetFocusOnClick(false)
+		b.Clicked(func() {
+			le.apply(f(le.Items()))
+		})
+		return b
+	}
+	for i, item := range items {
+		i := i
+		row := gtk.NewHBox(false, 1)
+		e := gtk.NewEntry()
+		e.SetText(item)
+		e.Connect("activate", func() {
+			le.apply(le.Items())
+		})
+		e.Connect("focus-out-event", func() {
+			le.apply(le.Items())
+		})
+		up := button("↑", func(l []string) []string {
+			l[i-1], l[i] = l[i], l[i-1]
+			return l
+		})
+		up.SetSensitive(i > 0)
+		down := button("↓", func(l []string) []string {
+			l[i], l[i+1] = l[i+1], l[i]
+			return l
+		})
+		down.SetSensitive(i < len(items)-1)
+		remove := button("✕", func(l []string) []string {
+			return append(l[:i], l[i+1:]...)
+		})
+		row.PackStart(e, true, true, 0)
+		row.PackStart(up, false, false, 0)
+		row.PackStart(down, false, false, 0)
+		row.PackStart(remove, false, false, 0)
+		le.PackStart(row, false, false, 0)
+		row.ShowAll()
+		le.rows = append(le.rows, row)
+		le.Entries = append(le.Entries, e)
+	}
+}
+
+// NewGtkListParamWidget edits a list parameter with a GtkListEditor. An
+// edited item is applied when Enter is pressed or its entry loses focus.
+func NewGtkListParamWidget(p rv.RenderParameter, w *GtkRenderWidget) *GtkParamWidget {
+	le := &GtkListEditor{
+		VBox: gtk.NewVBox(false, 1),
+		Add:  gtk.NewButtonWithLabel("Add"),
+	}
+	le.PackEnd(le.Add, false, false, 0)
+	r := &GtkParamWidget{
+		IWidget: le,
+		P:       p,
+	}
+	le.apply = func(items []string) {
+		if r.updating || rv.FormatList(items) == rv.FormatList(rv.GetListItems(r.P)) {
+			return
+		}
+		var err error
+		r.set(func() { err = rv.SetListItems(r.P, items) })
+		if err != nil {
+			ShowErrorDialog(w.GetTopLevelAsWindow(), err)
+		}
+		r.Update()
+		w.SetNeedsPaint()
+	}
+	le.Add.Clicked(func() {
+		items := le.Items()
+		le.apply(append(items, rv.NewListItem(r.P, items)))
+	})
+	r.Update()
+	return r
+}
+
+// NewGtkScaleParamWidget edits a bounded int or float64 parameter with a
+// Scale, which can be dragged or stepped with the mouse wheel
+func NewGtkScaleParamWidget(p rv.RenderParameter, w *GtkRenderWidget) *GtkParamWidget {
+	min, max, step, _ := p.GetRange()
+	if step <= 0 {
+		step = (max - min) / 100
+	}
+	sc := gtk.NewHScaleWithRange(min, max, step)
+	r := &GtkParamWidget{
+		IWidget: sc,
+		P:       p,
+	}
+	r.Update()
+	sc.Connect("value-changed", func() {
+		v := sc.GetValue()
+		if v != rv.GetParameterValueAsFloat64(r.P) {
+			r.set(func() { rv.SetParameterValueFromFloat64(r.P, v) })
+			w.SetNeedsPaint()
 		}
+	})
+	return r
+}
+
+// NewGtkColorParamWidget edits a color parameter with a ColorButton
+// opening the native color chooser
+func NewGtkColorParamWidget(p rv.RenderParameter, w *GtkRenderWidget) *GtkParamWidget {
+	cb := gtk.NewColorButton()
+	r := &GtkParamWidget{
+		IWidget: cb,
+		P:       p,
+	}
+	cb.SetUseAlpha(true)
+	r.Update()
+	cb.Connect("color-set", func() {
+		c := cb.GetColor()
+		r.set(func() {
+			r.P.SetValueColor(color.RGBA{uint8(c.Red() >> 8), uint8(c.Green() >> 8), uint8(c.Blue() >> 8), uint8(cb.GetAlpha() >> 8)})
+		})
 		w.SetNeedsPaint()
 	})
 	return r
 }
 
 func (w *GtkParamWidget) Update() {
+	w.updating = true
+	defer func() { w.updating = false }()
 	switch a := w.IWidget.(type) {
 	case *gtk.TextView:
 		tb := a.GetBuffer()
-		tb.SetText(rv.GetParameterValueAsString(w.P))
+		tb.SetText(rv.FormatParameterValue(w.P))
+		w.ShowError(nil)
+	case *gtk.Label:
+		a.SetText(rv.FormatParameterValue(w.P))
+	case *gtk.CheckButton:
+		a.SetActive(w.P.GetValueBool())
+	case *GtkPathChooser:
+		a.Entry.SetText(w.P.GetValueString())
+	case *GtkListEditor:
+		a.SetItems(rv.GetListItems(w.P))
+	case *gtk.Scale:
+		a.SetValue(rv.GetParameterValueAsFloat64(w.P))
+	case *gtk.ColorButton:
+		c := w.P.GetValueColor()
+		a.SetColor(gdk.NewColorRGB(uint16(c.R)*257, uint16(c.G)*257, uint16(c.B)*257))
+		a.SetAlpha(uint16(c.A) * 257)
+	case *gtk.ComboBoxText:
+		v := w.P.GetValueString()
+		for i, c := range w.P.GetChoices() {
+			if c == v {
+				a.SetActive(i)
+				break
+			}
+		}
+	}
+}
+
+// ShowError tints the text widget and shows err below it, or clears the
+// error state if err is nil. The parameter keeps its last good value.
+func (w *GtkParamWidget) ShowError(err error) {
+	tv, ok := w.IWidget.(*gtk.TextView)
+	if !ok || w.Message == nil {
+		return
+	}
+	if err == nil {
+		tv.ModifyBase(gtk.STATE_NORMAL, w.base)
+		tv.SetTooltipText(w.P.GetDescription())
+		w.Message.Hide()
+		return
+	}
+	msg := err.Error()
+	if pe, ok := err.(*rv.ParseError); ok {
+		msg = pe.Err.Error()
 	}
+	tv.ModifyBase(gtk.STATE_NORMAL, gdk.NewColor("#ffd0d0"))
+	tv.SetTooltipText(err.Error())
+	w.Message.SetText(msg)
+	w.Message.Show()
 }
@@ -0,0 +1,42 @@
+// Copyright 2020 Howard C. Shaw III. All rights reserved.
+// Use of this source code is governed by the MIT-license
+// as defined in the LICENSE file.
+
+// +build android shiny
+
+package shiny
+
+import (
+	"log"
+
+	rv "github.com/TheGrum/renderview"
+)
+
+// saveParameterFile saves the parameters to rv.DefaultParameterFile
+func saveParameterFile(r rv.RenderModel) {
+	filename := rv.DefaultParameterFile()
+	if err := rv.SaveParametersToFile(filename, r); err != nil {
+		log.Printf("renderview: saving parameters: %v", err)
+	} else {
+		log.Printf("renderview: parameters saved to %v", filename)
+	}
+}
+
+// loadParameterFile loads the parameters from rv.DefaultParameterFile
+func loadParameterFile(r rv.RenderModel) {
+	if err := rv.LoadParametersFromFile(rv.DefaultParameterFile(), r); err != nil {
+		log.Printf("renderview: loading parameters: %v", err)
+	}
+}
+
+func restoreSession(r rv.RenderModel) {
+	if err := rv.RestoreSession(r); err != nil {
+		log.Printf("renderview: restoring session: %v", err)
+	}
+}
+
+func saveSession(r rv.RenderModel) {
+	if err := rv.SaveSession(r); err != nil {
+		log.Printf("renderview: saving session: %v", err)
+	}
+}
@@ -0,0 +1,38 @@
+// Copyright 2020 Howard C. Shaw III. All rights reserved.
+// Use of this source code is governed by the MIT-license
+// as defined in the LICENSE file.
+
+// +build android shiny
+
+package shiny
+
+import (
+	rv "github.com/TheGrum/renderview"
+
+	"golang.org/x/mobile/event/key"
+)
+
+// HandleShortcuts handles the keys that work anywhere in the window,
+// reporting whether e was one of them: Ctrl+S saves the parameters to
+// rv.DefaultParameterFile and Ctrl+O loads them from it, Ctrl+Z undoes
+// the last change and Ctrl+Shift+Z redoes it
+func HandleShortcuts(e key.Event, r rv.RenderModel) bool {
+	if e.Direction != key.DirPress || e.Modifiers&key.ModControl == 0 {
+		return false
+	}
+	switch e.Code {
+	case key.CodeS:
+		saveParameterFile(r)
+	case key.CodeO:
+		loadParameterFile(r)
+	case key.CodeZ:
+		if e.Modifiers&key.ModShift != 0 {
+			r.GetHistory().Redo()
+		} else {
+			r.GetHistory().Undo()
+		}
+	default:
+		return false
+	}
+	return true
+}
@@ -50,24 +50,17 @@ func MainLoop(s screen.Screen, r rv.RenderModel) {
 	var needsPaint = false
 	var mouseIsDown = false
 	var dragging bool = false
-	var dx, dy float64
 	var sx, sy float32
 
-	var left, top, right, bottom, zoom rv.RenderParameter
-	left = r.GetParameter("left")
-	top = r.GetParameter("top")
-	right = r.GetParameter("right")
-	bottom = r.GetParameter("bottom")
-	zoom = r.GetParameter("zoom")
 	mouseX := r.GetParameter("mouseX")
 	mouseY := r.GetParameter("mouseY")
-	options := r.GetParameter("options")
 	page := r.GetParameter("page")
 	//	offsetX := r.GetParameter("offsetX")
 	//	offsetY := r.GetParameter("offsetY")
 
-	leftIsFloat64 := left.GetType() == "float64"
-	zoomIsFloat64 := zoom.GetType() == "float64"
+	view := rv.NewViewport(r)
+
+	defer saveSession(r)
 
 	w, err := s.NewWindow(nil)
 	if err != nil {
@@ -90,6 +83,8 @@ func MainLoop(s screen.Screen, r rv.RenderModel) {
 		needsPaint = true
 		w.Send(paint.Event{})
 	})
+	// restoring may request a paint, so it waits for the paint function
+	restoreSession(r)
 
 	for {
 		switch e := w.NextEvent().(type) {
@@ -106,19 +101,30 @@ func MainLoop(s screen.Screen, r rv.RenderModel) {
 			if e.Code == key.CodeEscape {
 				return
 			}
+			if HandleShortcuts(e, r) {
+				needsPaint = true
+			}
 			if e.Code == key.CodePageUp && e.Direction == key.DirPress {
+				r.GetHistory().Checkpoint("page")
+				r.Lock()
 				page.SetValueInt(page.GetValueInt() - 1)
+				r.Unlock()
 				needsPaint = true
 			}
 			if e.Code == key.CodePageDown && e.Direction == key.DirPress {
+				r.GetHistory().Checkpoint("page")
+				r.Lock()
 				page.SetValueInt(page.GetValueInt() + 1)
+				r.Unlock()
 				needsPaint = true
 			}
 
 		case mouse.Event:
 			//fmt.Printf("mouse pos(%v)\n", e)
+			r.Lock()
 			mouseX.SetValueFloat64(float64(e.X))
 			mouseY.SetValueFloat64(float64(e.Y))
+			r.Unlock()
 
 			if dragging == false && e.Direction == mouse.DirPress && e.Button == mouse.ButtonLeft {
 				//				fmt.Printf("mouse down left(%v)\n", e)
@@ -127,62 +133,18 @@ func MainLoop(s screen.Screen, r rv.RenderModel) {
 				mouseIsDown = true
 			}
 			if e.Button == mouse.ButtonWheelDown {
-				if zoomIsFloat64 {
-					zoom.SetValueFloat64(zoom.GetValueFloat64() - 1)
-				} else {
-					zoom.SetValueInt(zoom.GetValueInt() - 1)
-				}
-				if options.GetValueInt()&rv.OPT_AUTO_ZOOM == rv.OPT_AUTO_ZOOM || options.GetValueInt()&rv.OPT_CENTER_ZOOM == rv.OPT_CENTER_ZOOM {
-					mult := 1 + rv.ZOOM_RATE
-					if leftIsFloat64 {
-
-						zwidth := right.GetValueFloat64() - left.GetValueFloat64()
-						zheight := bottom.GetValueFloat64() - top.GetValueFloat64()
-						nzwidth := zwidth * mult
-						nzheight := zheight * mult
-						cx := float64(e.X) / float64(buf.Size().X)
-						cy := float64(e.Y) / float64(buf.Size().Y)
-						if options.GetValueInt()&rv.OPT_CENTER_ZOOM == rv.OPT_CENTER_ZOOM {
-							cx = 0.5
-							cy = 0.5
-						}
-						//fmt.Printf("zoomOut: mult: %v zwidth: %v nzwidth: %v cx: %v left: %v nleft: %v\n", mult, zwidth, nzwidth, cx, left.GetValueFloat64(), left.GetValueFloat64()-((nzwidth-zwidth)*cx))
-						left.SetValueFloat64(left.GetValueFloat64() - ((nzwidth - zwidth) * cx))
-						top.SetValueFloat64(top.GetValueFloat64() - ((nzheight - zheight) * cy))
-						right.SetValueFloat64(left.GetValueFloat64() + nzwidth)
-						bottom.SetValueFloat64(top.GetValueFloat64() + nzheight)
-						needsPaint = true
-
-					}
-				}
-
+				r.GetHistory().Checkpoint("zoom")
+				r.Lock()
+				view.ZoomAt(float64(e.X), float64(e.Y), true)
+				needsPaint = true
+				r.Unlock()
 			}
 			if e.Button == mouse.ButtonWheelUp {
-				if zoomIsFloat64 {
-					zoom.SetValueFloat64(zoom.GetValueFloat64() + 1)
-				} else {
-					zoom.SetValueInt(zoom.GetValueInt() + 1)
-				}
-				if options.GetValueInt()&rv.OPT_AUTO_ZOOM == rv.OPT_AUTO_ZOOM || options.GetValueInt()&rv.OPT_CENTER_ZOOM == rv.OPT_CENTER_ZOOM {
-					mult := 1 - rv.ZOOM_RATE
-					if leftIsFloat64 {
-						zwidth := right.GetValueFloat64() - left.GetValueFloat64()
-						zheight := bottom.GetValueFloat64() - top.GetValueFloat64()
-						nzwidth := zwidth * mult
-						nzheight := zheight * mult
-						cx := float64(e.X) / float64(buf.Size().X)
-						cy := float64(e.Y) / float64(buf.Size().Y)
-						if options.GetValueInt()&rv.OPT_CENTER_ZOOM == rv.OPT_CENTER_ZOOM {
-							cx = 0.5
-							cy = 0.5
-						}
-						left.SetValueFloat64(left.GetValueFloat64() - ((nzwidth - zwidth) * cx))
-						top.SetValueFloat64(top.GetValueFloat64() - ((nzheight - zheight) * cy))
-						right.SetValueFloat64(left.GetValueFloat64() + nzwidth)
-						bottom.SetValueFloat64(top.GetValueFloat64() + nzheight)
-						needsPaint = true
-					}
-				}
+				r.GetHistory().Checkpoint("zoom")
+				r.Lock()
+				view.ZoomAt(float64(e.X), float64(e.Y), false)
+				needsPaint = true
+				r.Unlock()
 			}
 			if e.Direction == mouse.DirNone && mouseIsDown {
 				//				fmt.Printf("mouse drag(%v) dragging (%v)\n", e, dragging)
@@ -193,31 +155,13 @@ func MainLoop(s screen.Screen, r rv.RenderModel) {
 						//						fmt.Printf("Dragging.\n")
 					}
 				} else {
-					if leftIsFloat64 {
-						width := right.GetValueFloat64() - left.GetValueFloat64()
-						height := bottom.GetValueFloat64() - top.GetValueFloat64()
-						dx = width / float64(buf.Size().X)
-						dy = height / float64(buf.Size().Y)
-						cx := float64(e.X-sx) * dx
-						cy := float64(e.Y-sy) * dy
-						//						fmt.Printf("dx %v dy %v cx %v cy %v\n", dx, dy, cx, cy)
-						left.SetValueFloat64(left.GetValueFloat64() - cx)
-						right.SetValueFloat64(right.GetValueFloat64() - cx)
-						top.SetValueFloat64(top.GetValueFloat64() - cy)
-						bottom.SetValueFloat64(bottom.GetValueFloat64() - cy)
-					} else {
-						width := right.GetValueInt() - left.GetValueInt()
-						height := bottom.GetValueInt() - top.GetValueInt()
-						dx = float64(width) / float64(buf.Size().X)
-						dy = float64(height) / float64(buf.Size().Y)
-						cx := float64(e.X-sx) * dx
-						cy := float64(e.Y-sy) * dy
-						left.SetValueInt(int(float64(left.GetValueInt()) - cx))
-						right.SetValueInt(int(float64(right.GetValueInt()) - cx))
-						top.SetValueInt(int(float64(top.GetValueInt()) - cy))
-						bottom.SetValueInt(int(float64(bottom.GetValueInt()) - cy))
-					}
+					r.GetHistory().Checkpoint("pan")
+					r.Lock()
+					view.Pan(float64(e.X-sx), float64(e.Y-sy))
+					r.Unlock()
 					Draw(r.Render(), buf.RGBA())
+					DrawMarkers(r, buf.RGBA())
+					rv.DrawProgress(buf.RGBA(), buf.RGBA().Bounds(), rv.GetProgress(r))
 
 					sx = e.X
 					sy = e.Y
@@ -225,6 +169,10 @@ func MainLoop(s screen.Screen, r rv.RenderModel) {
 				}
 			}
 			if e.Direction == mouse.DirRelease {
+				// a click, rather than the end of a drag, picks a point
+				if mouseIsDown && !dragging && rv.PickAt(r, float64(e.X), float64(e.Y)) {
+					needsPaint = true
+				}
 				dragging = false
 				mouseIsDown = false
 			}
@@ -233,24 +181,43 @@ func MainLoop(s screen.Screen, r rv.RenderModel) {
 			if buf != nil {
 				buf.Release()
 			}
+			r.Lock()
 			r.GetParameter("width").SetValueInt(e.Size().X)
 			r.GetParameter("height").SetValueInt(e.Size().Y)
+			r.Unlock()
 			buf, err = s.NewBuffer(e.Size())
 			if err != nil {
 				log.Fatal(err)
 			}
 			Draw(r.Render(), buf.RGBA())
+			DrawMarkers(r, buf.RGBA())
+			rv.DrawProgress(buf.RGBA(), buf.RGBA().Bounds(), rv.GetProgress(r))
 		default:
 
 		}
 		if needsPaint {
 			needsPaint = false
 			Draw(r.Render(), buf.RGBA())
+			DrawMarkers(r, buf.RGBA())
+			rv.DrawProgress(buf.RGBA(), buf.RGBA().Bounds(), rv.GetProgress(r))
 			w.Send(paint.Event{})
 		}
 	}
 }
 
+// DrawMarkers draws a crosshair over bimg at each point picked with HINT_PICK
+func DrawMarkers(r rv.RenderModel, bimg *image.RGBA) {
+	if bimg == nil {
+		return
+	}
+	r.Lock()
+	markers := rv.PickMarkers(r)
+	r.Unlock()
+	for _, pt := range markers {
+		rv.DrawCrosshair(bimg, pt)
+	}
+}
+
 func Draw(mimg image.Image, bimg *image.RGBA) {
 	if !(mimg == nil) && !(bimg == nil) {
 		r := mimg.Bounds()
@@ -290,23 +257,18 @@ type RenderWidget struct {
 	index int
 	r     rv.RenderModel
 
-	left,
-	top,
-	right,
-	bottom,
 	width,
 	height,
-	zoom,
 	mouseX,
 	mouseY,
-	options,
 	page rv.RenderParameter
 
+	// view pans and zooms the model's viewport parameters
+	view *rv.Viewport
+
 	sx,
 	sy float32
 
-	leftIsFloat64,
-	zoomIsFloat64,
 	mouseIsDown,
 	dragging bool
 }
@@ -316,19 +278,12 @@ func NewRenderWidget(r rv.RenderModel) *RenderWidget {
 		r: r,
 	}
 	w.Wrapper = w
-	w.left = r.GetParameter("left")
-	w.top = r.GetParameter("top")
-	w.right = r.GetParameter("right")
-	w.bottom = r.GetParameter("bottom")
 	w.width = r.GetParameter("width")
 	w.height = r.GetParameter("height")
-	w.zoom = r.GetParameter("zoom")
 	w.mouseX = r.GetParameter("mouseX")
 	w.mouseY = r.GetParameter("mouseY")
-	w.options = r.GetParameter("options")
 	w.page = r.GetParameter("page")
-	w.leftIsFloat64 = w.left.GetType() == "float64"
-	w.zoomIsFloat64 = w.zoom.GetType() == "float64"
+	w.view = rv.NewViewport(r)
 	r.SetRequestPaintFunc(func() {
 		w.Mark(node.MarkNeedsPaintBase)
 	})
@@ -341,19 +296,30 @@ func (m *RenderWidget) OnInputEvent(e interface{}, origin image.Point) node.Even
 		if e.Code == key.CodeEscape {
 			return node.NotHandled
 		}
+		if HandleShortcuts(e, m.r) {
+			m.Mark(node.MarkNeedsPaintBase)
+		}
 		if e.Code == key.CodePageUp && e.Direction == key.DirPress {
+			m.r.GetHistory().Checkpoint("page")
+			m.r.Lock()
 			m.page.SetValueInt(m.page.GetValueInt() - 1)
+			m.r.Unlock()
 			m.Mark(node.MarkNeedsPaintBase)
 		}
 		if e.Code == key.CodePageDown && e.Direction == key.DirPress {
+			m.r.GetHistory().Checkpoint("page")
+			m.r.Lock()
 			m.page.SetValueInt(m.page.GetValueInt() + 1)
+			m.r.Unlock()
 			m.Mark(node.MarkNeedsPaintBase)
 		}
 
 	case mouse.Event:
 		//fmt.Printf("mouse pos(%v)\n", e)
+		m.r.Lock()
 		m.mouseX.SetValueFloat64(float64(e.X))
 		m.mouseY.SetValueFloat64(float64(e.Y))
+		m.r.Unlock()
 
 		if m.dragging == false && e.Direction == mouse.DirPress && e.Button == mouse.ButtonLeft {
 			//				fmt.Printf("mouse down left(%v)\n", e)
@@ -362,64 +328,18 @@ func (m *RenderWidget) OnInputEvent(e interface{}, origin image.Point) node.Even
 			m.mouseIsDown = true
 		}
 		if e.Button == mouse.ButtonWheelDown {
-			if m.zoomIsFloat64 {
-				m.zoom.SetValueFloat64(m.zoom.GetValueFloat64() - 1)
-			} else {
-				m.zoom.SetValueInt(m.zoom.GetValueInt() - 1)
-			}
-			if m.options.GetValueInt()&rv.OPT_AUTO_ZOOM == rv.OPT_AUTO_ZOOM || m.options.GetValueInt()&rv.OPT_CENTER_ZOOM == rv.OPT_CENTER_ZOOM {
-				mult := 1 + rv.ZOOM_RATE
-				if m.leftIsFloat64 {
-
-					zwidth := m.right.GetValueFloat64() - m.left.GetValueFloat64()
-					zheight := m.bottom.GetValueFloat64() - m.top.GetValueFloat64()
-					nzwidth := zwidth * mult
-					nzheight := zheight * mult
-					cx := float64(e.X) / float64(m.width.GetValueInt())
-					cy := float64(e.Y) / float64(m.height.GetValueInt())
-					if m.options.GetValueInt()&rv.OPT_CENTER_ZOOM == rv.OPT_CENTER_ZOOM {
-						cx = 0.5
-						cy = 0.5
-					}
-					//fmt.Printf("zoomOut: mult: %v zwidth: %v nzwidth: %v cx: %v left: %v nleft: %v\n", mult, zwidth, nzwidth, cx, left.GetValueFloat64(), left.GetValueFloat64()-((nzwidth-zwidth)*cx))
-					m.left.SetValueFloat64(m.left.GetValueFloat64() - ((nzwidth - zwidth) * cx))
-					m.top.SetValueFloat64(m.top.GetValueFloat64() - ((nzheight - zheight) * cy))
-					m.right.SetValueFloat64(m.left.GetValueFloat64() + nzwidth)
-					m.bottom.SetValueFloat64(m.top.GetValueFloat64() + nzheight)
-
-					m.Mark(node.MarkNeedsPaintBase)
-
-				}
-			}
-
+			m.r.GetHistory().Checkpoint("zoom")
+			m.r.Lock()
+			m.view.ZoomAt(float64(e.X), float64(e.Y), true)
+			m.Mark(node.MarkNeedsPaintBase)
+			m.r.Unlock()
 		}
 		if e.Button == mouse.ButtonWheelUp {
-			if m.zoomIsFloat64 {
-				m.zoom.SetValueFloat64(m.zoom.GetValueFloat64() + 1)
-			} else {
-				m.zoom.SetValueInt(m.zoom.GetValueInt() + 1)
-			}
-			if m.options.GetValueInt()&rv.OPT_AUTO_ZOOM == rv.OPT_AUTO_ZOOM || m.options.GetValueInt()&rv.OPT_CENTER_ZOOM == rv.OPT_CENTER_ZOOM {
-				mult := 1 - rv.ZOOM_RATE
-				if m.leftIsFloat64 {
-					zwidth := m.right.GetValueFloat64() - m.left.GetValueFloat64()
-					zheight := m.bottom.GetValueFloat64() - m.top.GetValueFloat64()
-					nzwidth := zwidth * mult
-					nzheight := zheight * mult
-					cx := float64(e.X) / float64(m.width.GetValueInt())
-
-					cy := float64(e.Y) / float64(m.height.GetValueInt())
-					if m.options.GetValueInt()&rv.OPT_CENTER_ZOOM == rv.OPT_CENTER_ZOOM {
-						cx = 0.5
-						cy = 0.5
-					}
-					m.left.SetValueFloat64(m.left.GetValueFloat64() - ((nzwidth - zwidth) * cx))
-					m.top.SetValueFloat64(m.top.GetValueFloat64() - ((nzheight - zheight) * cy))
-					m.right.SetValueFloat64(m.left.GetValueFloat64() + nzwidth)
-					m.bottom.SetValueFloat64(m.top.GetValueFloat64() + nzheight)
-					m.Mark(node.MarkNeedsPaintBase)
-				}
-			}
+			m.r.GetHistory().Checkpoint("zoom")
+			m.r.Lock()
+			m.view.ZoomAt(float64(e.X), float64(e.Y), false)
+			m.Mark(node.MarkNeedsPaintBase)
+			m.r.Unlock()
 		}
 		if e.Direction == mouse.DirNone && m.mouseIsDown {
 			//				fmt.Printf("mouse drag(%v) dragging (%v)\n", e, dragging)
@@ -430,30 +350,10 @@ func (m *RenderWidget) OnInputEvent(e interface{}, origin image.Point) node.Even
 					//						fmt.Printf("Dragging.\n")
 				}
 			} else {
-				if m.leftIsFloat64 {
-					width := m.right.GetValueFloat64() - m.left.GetValueFloat64()
-					height := m.bottom.GetValueFloat64() - m.top.GetValueFloat64()
-					dx := width / float64(m.width.GetValueInt())
-					dy := height / float64(m.height.GetValueInt())
-					cx := float64(e.X-m.sx) * dx
-					cy := float64(e.Y-m.sy) * dy
-					//						fmt.Printf("dx %v dy %v cx %v cy %v\n", dx, dy, cx, cy)
-					m.left.SetValueFloat64(m.left.GetValueFloat64() - cx)
-					m.right.SetValueFloat64(m.right.GetValueFloat64() - cx)
-					m.top.SetValueFloat64(m.top.GetValueFloat64() - cy)
-					m.bottom.SetValueFloat64(m.bottom.GetValueFloat64() - cy)
-				} else {
-					width := m.right.GetValueInt() - m.left.GetValueInt()
-					height := m.bottom.GetValueInt() - m.top.GetValueInt()
-					dx := float64(width) / float64(m.Rect.Dx())
-					dy := float64(height) / float64(m.Rect.Dy())
-					cx := float64(e.X-m.sx) * dx
-					cy := float64(e.Y-m.sy) * dy
-					m.left.SetValueInt(int(float64(m.left.GetValueInt()) - cx))
-					m.right.SetValueInt(int(float64(m.right.GetValueInt()) - cx))
-					m.top.SetValueInt(int(float64(m.top.GetValueInt()) - cy))
-					m.bottom.SetValueInt(int(float64(m.bottom.GetValueInt()) - cy))
-				}
+				m.r.GetHistory().Checkpoint("pan")
+				m.r.Lock()
+				m.view.Pan(float64(e.X-m.sx), float64(e.Y-m.sy))
+				m.r.Unlock()
 				m.Mark(node.MarkNeedsPaintBase)
 				//			Draw(r.Render(), buf.RGBA())
 
@@ -463,6 +363,10 @@ func (m *RenderWidget) OnInputEvent(e interface{}, origin image.Point) node.Even
 			}
 		}
 		if e.Direction == mouse.DirRelease {
+			// a click, rather than the end of a drag, picks a point
+			if m.mouseIsDown && !m.dragging && rv.PickAt(m.r, float64(e.X), float64(e.Y)) {
+				m.Mark(node.MarkNeedsPaintBase)
+			}
 			m.dragging = false
 			m.mouseIsDown = false
 		}
@@ -472,15 +376,22 @@ func (m *RenderWidget) OnInputEvent(e interface{}, origin image.Point) node.Even
 }
 
 func (m *RenderWidget) PaintBase(ctx *node.PaintBaseContext, origin image.Point) error {
+	m.r.Lock()
 	m.width.SetValueInt(m.Rect.Dx())
 	m.height.SetValueInt(m.Rect.Dy())
+	m.r.Unlock()
 	m.Marks.UnmarkNeedsPaintBase()
 	Draw(m.r.Render(), ctx.Dst)
+	DrawMarkers(m.r, ctx.Dst)
+	rv.DrawProgress(ctx.Dst, ctx.Dst.Bounds(), rv.GetProgress(m.r))
 	return nil
 }
 
 func WidgetMainLoop(s screen.Screen, r rv.RenderModel) {
+	defer saveSession(r)
 	w := GetRenderWidgetWithSidebar(r)
+	// the widget has set the paint function that restoring may call
+	restoreSession(r)
 	if err := widget.RunWindow(s, w, nil); err != nil {
 		log.Fatal(err)
 	}
@@ -15,36 +15,44 @@ import (
 	"time"
 
 	rv "github.com/TheGrum/renderview"
-	"github.com/TheGrum/renderview/driver/shiny"
+	"github.com/TheGrum/renderview/driver"
 )
 
 func main() {
 	flag.Parse()
 
-	var (
-		f   *os.File
-		err error
-	)
+	filename := "test.gif"
 	if flag.NArg() > 0 {
-		f, err = os.Open(flag.Arg(0))
-		handleError(err)
-	} else {
-		f, err = os.Open("test.gif")
-		handleError(err)
+		filename = flag.Arg(0)
 	}
 
-	images, err := gif.DecodeAll(f)
-	handleError(err)
-	f.Close()
-
-	numImages := len(images.Image)
-
+	var (
+		images *gif.GIF
+		loaded string
+	)
 	start := time.Now()
 	m := rv.NewBasicRenderModel()
-	m.AddParameters(rv.NewIntRP("page", 0))
+	m.AddParameters(rv.SetHints(rv.HINT_SIDEBAR,
+		rv.NewPathRP("file", filename, rv.PATH_OPEN, "*.gif"),
+		rv.NewIntRP("page", 0))...)
 	page := m.GetParameter("page")
-	m.InnerRender = func() {
-		p := page.GetValueInt()
+	m.InnerRender = func(s rv.Snapshot) {
+		if name := s.String("file"); name != loaded {
+			loaded = name
+			g, err := loadGIF(name)
+			if err != nil {
+				log.Print(err)
+			} else {
+				images = g
+			}
+		}
+		if images == nil || len(images.Image) == 0 {
+			return
+		}
+		numImages := len(images.Image)
+		p := s.Int("page")
+		m.Lock()
+		defer m.Unlock()
 		if p > numImages {
 			p = numImages
 			page.SetValueInt(p)
@@ -65,7 +73,16 @@ func main() {
 			m.RequestPaint()
 		}
 	}(m)
-	shiny.FrameBuffer(m)
+	driver.Main(m)
+}
+
+func loadGIF(name string) (*gif.GIF, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return gif.DecodeAll(f)
 }
 
 func handleError(err error) {
@@ -33,13 +33,19 @@ func main() {
 	m.AddParameters(rv.SetHints(rv.HINT_FULLTEXT, lsystemRP)...)
 	m.AddParameters(
 		rv.SetHints(rv.HINT_FOOTER,
-			rv.NewFloat64RP("angle", 90),
-			rv.NewIntRP("depth", 5))...)
+			rv.NewBoundedFloat64RP("angle", 90, 0, 180, 0.5),
+			rv.NewBoundedIntRP("depth", 5, 0, 20, 1))...)
+	m.AddParameters(
+		rv.SetHints(rv.HINT_FOOTER|rv.HINT_READONLY,
+			rv.NewIntRP("length", 0))...)
 	c := rv.NewChangeMonitor()
 	c.AddParameters(m.Params[8], m.Params[10]) // lsystem, depth
-	m.InnerRender = func() {
-		m.Img = RenderLSystemModel(m, c)
+	m.InnerRender = func(s rv.Snapshot) {
+		img := RenderLSystemModel(m, s, c)
+		m.Lock()
+		m.Img = img
 		m.NeedsRender = true
+		m.Unlock()
 	}
 	m.NeedsRender = true
 	driver.Main(m)
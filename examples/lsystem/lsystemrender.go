@@ -49,29 +49,24 @@ func FMax(a float64, b float64) float64 {
 	return b
 }
 
-func RenderLSystemModel(m *rv.BasicRenderModel, c *rv.ChangeMonitor) image.Image {
-	left := m.Params[0].GetValueFloat64()
-	top := m.Params[1].GetValueFloat64()
-	right := m.Params[2].GetValueFloat64()
-	bottom := m.Params[3].GetValueFloat64()
-	width := m.Params[4].GetValueInt()
-	height := m.Params[5].GetValueInt()
-
-	lsystem := m.GetParameter("lsystem").GetValueString()
-	angle := m.GetParameter("angle").GetValueFloat64()
-	depth := m.GetParameter("depth").GetValueInt()
-	if depth > 20 {
-		depth = 20
-		m.GetParameter("depth").SetValueInt(20)
-	}
+func RenderLSystemModel(m *rv.BasicRenderModel, s rv.Snapshot, c *rv.ChangeMonitor) image.Image {
+	left := s.Float64("left")
+	top := s.Float64("top")
+	right := s.Float64("right")
+	bottom := s.Float64("bottom")
+	width := s.Int("width")
+	height := s.Int("height")
+
+	lsystem := s.String("lsystem")
+	angle := s.Float64("angle")
+	depth := s.Int("depth")
 	bounds := image.Rect(0, 0, width, height)
-	result := m.GetParameter("LSystemResult").GetValueString()
+	result := s.String("LSystemResult")
 	magnitude := 1.0 //5 * float64(width) / (right - left)
 
 	if c.HasChanged() {
 		// lsystem or depth has changed, recalculate
 		result = Calculate(lsystem, depth)
-		m.GetParameter("LSystemResult").SetValueString(result)
 		_, minX, minY, dx, dy := RenderLSystem(left, top, right, bottom, bounds, angle, 1, result)
 		//fmt.Printf("Applying %v,%v %vx%v mag:%v calmag:%v\n", minX, minY, dx, dy, magnitude, 5*(dx/float64(width)))
 		//mult := (float64(width) / dx) / 5
@@ -83,10 +78,14 @@ func RenderLSystemModel(m *rv.BasicRenderModel, c *rv.ChangeMonitor) image.Image
 		//fmt.Printf("Final %v,%v,%v,%v\n", left, top, right, bottom)
 		magnitude = 1.0 //5 * float64(width) / (right - left)
 
+		m.Lock()
+		m.GetParameter("LSystemResult").SetValueString(result)
+		m.GetParameter("length").SetValueInt(len(result))
 		m.Params[0].SetValueFloat64(left)
 		m.Params[1].SetValueFloat64(top)
 		m.Params[2].SetValueFloat64(right)
 		m.Params[3].SetValueFloat64(bottom)
+		m.Unlock()
 		m.RequestPaint()
 
 	}
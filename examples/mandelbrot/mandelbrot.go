@@ -9,31 +9,74 @@ package mandelbrot
 // minor changes to make it a callable function
 
 import (
+	"context"
 	"image"
 	"image/color"
 	"image/draw"
 	"math/cmplx"
+	"time"
 )
 
 func mandelbrot(a complex128, maxEsc float64) float64 {
+	return julia(a, a, maxEsc)
+}
+
+// julia iterates z*z + c from z, as mandelbrot does with c = z
+func julia(z complex128, c complex128, maxEsc float64) float64 {
 	i := 0.0
-	for z := a; cmplx.Abs(z) < 2 && i < maxEsc; i++ {
-		z = z*z + a
+	for ; cmplx.Abs(z) < 2 && i < maxEsc; i++ {
+		z = z*z + c
 	}
 	return float64(maxEsc-i) / maxEsc
 }
 
-func generateMandelbrot(rMin, iMin, rMax, iMax float64, width, red, green, blue int, maxEsc int) image.Image {
+// posterize steps f down to the largest of bands not above it, leaving
+// f alone if there are no bands
+func posterize(f float64, bands []float64) float64 {
+	if len(bands) == 0 {
+		return f
+	}
+	r := 0.0
+	for _, b := range bands {
+		if b <= f && b > r {
+			r = b
+		}
+	}
+	return r
+}
+
+// generateMandelbrot draws the region rMin+iMin i to rMax+iMax i of the
+// Mandelbrot set, or with isJulia the Julia set of c, at width x height.
+// It hands publish a copy of the columns drawn so far every tenth of a
+// second, and gives up, returning nil, once ctx is canceled.
+func generateMandelbrot(ctx context.Context, publish func(image.Image, float64), rMin, iMin, rMax, iMax float64, width, height, red, green, blue int, maxEsc int, bands []float64, isJulia bool, c complex128) image.Image {
 	scale := float64(width) / (rMax - rMin)
-	height := int(scale * (iMax - iMin))
+	yscale := float64(height) / (iMax - iMin)
 	bounds := image.Rect(0, 0, width, height)
 	b := image.NewRGBA(bounds)
 	draw.Draw(b, bounds, image.NewUniform(color.Black), image.ZP, draw.Src)
+	last := time.Now()
 	for x := 0; x < width; x++ {
+		if ctx.Err() != nil {
+			return nil
+		}
+		if publish != nil && time.Since(last) > 100*time.Millisecond {
+			partial := image.NewRGBA(bounds)
+			copy(partial.Pix, b.Pix)
+			publish(partial, float64(x)/float64(width))
+			last = time.Now()
+		}
 		for y := 0; y < height; y++ {
-			fEsc := mandelbrot(complex(
+			z := complex(
 				float64(x)/scale+rMin,
-				float64(y)/scale+iMin), float64(maxEsc))
+				float64(y)/yscale+iMin)
+			var fEsc float64
+			if isJulia {
+				fEsc = julia(z, c, float64(maxEsc))
+			} else {
+				fEsc = mandelbrot(z, float64(maxEsc))
+			}
+			fEsc = posterize(fEsc, bands)
 			b.Set(x, y, color.RGBA{uint8(float64(red) * fEsc),
 				uint8(float64(green) * fEsc), uint8(float64(blue) * fEsc), 255})
 
@@ -5,80 +5,113 @@
 package mandelbrot
 
 import (
+	"context"
+	"errors"
+	"image"
+	"image/color"
 	"math"
+	"time"
 
 	rv "github.com/TheGrum/renderview"
 )
 
 type MandelModel rv.BasicRenderModel
 
-func getInnerRenderFunc(m *MandelModel) func() {
-	return func() {
-		innerRender(m)
-	}
+// MandelConfig holds the view and coloring of the Mandelbrot set, bound
+// to the model's parameters by NewMandelModel
+type MandelConfig struct {
+	Left   float64    `rv:"left,group=View" format:"%.10g"`
+	Top    float64    `rv:"top,group=View" format:"%.10g"`
+	Right  float64    `rv:"right,group=View" format:"%.10g"`
+	Bottom float64    `rv:"bottom,group=View" format:"%.10g"`
+	MaxEsc int        `rv:"maxEsc,group=Rendering" label:"Max escape" unit:"iterations" desc:"Iterations before a point is taken to be in the set"`
+	Width  int        `rv:"width"`
+	Height int        `rv:"height"`
+	Tint   color.RGBA `rv:"tint,group=Rendering" desc:"Color of the points slowest to escape"`
+	Bands  []float64  `rv:"bands,group=Rendering" desc:"Shades between 0 and 1 the tint is stepped down to, or none for a smooth gradient"`
+	Julia  bool       `rv:"julia,group=Julia" label:"Julia set" desc:"Draw the Julia set of the constant instead of the Mandelbrot set"`
+	C      complex128 `rv:"c,pick,group=Julia" label:"Constant" desc:"Click in the image to pick the constant of the Julia set"`
+	MouseX float64    `rv:"mouseX"`
+	MouseY float64    `rv:"mouseY"`
+	// Elapsed is an output, the time the last render took
+	Elapsed int `rv:"elapsed,readonly,group=Rendering" label:"Render time" unit:"ms"`
 }
 
-func innerRender(m *MandelModel) {
-	var rMin, iMin, rMax, iMax float64
-	var width, red, green, blue int
-	var maxEsc int
-
-	m.Lock()
-	maxEsc = m.Params[4].GetValueInt()
-	rMin = m.Params[0].GetValueFloat64()
-	iMin = m.Params[1].GetValueFloat64()
-	rMax = m.Params[2].GetValueFloat64()
-	iMax = m.Params[3].GetValueFloat64()
-	width = m.Params[5].GetValueInt()
-	red = m.Params[7].GetValueInt()
-	green = m.Params[8].GetValueInt()
-	blue = m.Params[9].GetValueInt()
-	m.Rendering = true
-	m.Unlock()
+func getInnerRenderFunc(m *MandelModel) func(context.Context, rv.Snapshot) image.Image {
+	return func(ctx context.Context, s rv.Snapshot) image.Image {
+		return innerRender(ctx, m, s)
+	}
+}
 
-	i2 := generateMandelbrot(rMin, iMin, rMax, iMax, width, red, green, blue, maxEsc)
+// innerRender draws the view recorded in s, which the UI cannot change
+// while the render runs
+func innerRender(ctx context.Context, m *MandelModel, s rv.Snapshot) image.Image {
+	tint := s.Color("tint")
+	start := time.Now()
+	img := generateMandelbrot(ctx, (*rv.BasicRenderModel)(m).Publish,
+		s.Float64("left"), s.Float64("top"), s.Float64("right"), s.Float64("bottom"),
+		s.Int("width"), s.Int("height"), int(tint.R), int(tint.G), int(tint.B),
+		s.Int("maxEsc"), s.Float64s("bands"), s.Bool("julia"), s.Complex128("c"))
+	if img == nil {
+		return nil
+	}
 
 	m.Lock()
-	m.Img = i2
-	m.Rendering = false
+	m.GetParameter("elapsed").SetValueInt(int(time.Since(start) / time.Millisecond))
 	m.Unlock()
-	if !(m.RequestPaint == nil) {
-		m.RequestPaint()
-	}
+	return img
 }
 
 func NewMandelModel() *rv.BasicRenderModel {
 	m := rv.NewBasicRenderModel()
-	m.InnerRender = getInnerRenderFunc((*MandelModel)(m))
+	cfg := &MandelConfig{
+		Left:   -2,
+		Top:    -1,
+		Right:  0.5,
+		Bottom: 1,
+		MaxEsc: 100,
+		Width:  100,
+		Height: 100,
+		Tint:   color.RGBA{230, 235, 255, 255},
+		C:      complex(-0.8, 0.156),
+	}
+	m.InnerRenderContext = getInnerRenderFunc((*MandelModel)(m))
+	if err := m.BindStruct(cfg); err != nil {
+		panic(err)
+	}
 	m.AddParameters(
-		rv.NewFloat64RP("left", -2),
-		rv.NewFloat64RP("top", -1),
-		rv.NewFloat64RP("right", 0.5),
-		rv.NewFloat64RP("bottom", 1),
-		rv.NewIntRP("maxEsc", 100),
-		rv.NewIntRP("width", 100),
-		rv.NewIntRP("height", 100),
-		rv.NewIntRP("red", 230),
-		rv.NewIntRP("green", 235),
-		rv.NewIntRP("blue", 255),
-		rv.NewFloat64RP("mouseX", 0),
-		rv.NewFloat64RP("mouseY", 0),
-		NewZoomRP("zoom", 1, (*MandelModel)(m)),
+		NewZoomRP("zoom", 1, (*MandelModel)(m), cfg),
 		rv.NewIntRP("options", rv.OPT_NONE))
+	// look harder for escapes as the view zooms in
+	err := m.Derive("maxEsc", []string{"zoom"}, func(v ...interface{}) interface{} {
+		return 100 + int(math.Pow(1.1, float64(v[0].(int))))
+	})
+	if err != nil {
+		panic(err)
+	}
+	m.GetParameter("maxEsc").SetValidator(func(v interface{}) error {
+		if v.(int) < 1 {
+			return errors.New("must be at least 1")
+		}
+		return nil
+	})
+	m.SetGroupOrder("Rendering", "Julia", "View")
+	// bookmarks keep the escape limit, tint and Julia set along with the view
+	m.GetBookmarks().Include = []string{"maxEsc", "tint", "julia", "c"}
 	go m.GoRender()
 	return m
 }
 
 // Many applications can simply use OPT_AUTO_ZOOM
-// but since the Mandelbrot algorithm we are using ignores the height
-// and produces a square image, we use a custom parameter
-// to calculate the zoom ourselves, also taking the opportunity to
-// dynamically adjust the Escape parameter.
+// but this model zooms itself through a custom parameter,
+// which also serves as the input of the derived Escape
+// parameter, see NewMandelModel.
 type ZoomRenderParameter struct {
 	rv.EmptyParameter
 
-	Value int
-	Model *MandelModel
+	Value  int
+	Model  *MandelModel
+	Config *MandelConfig
 }
 
 func (e *ZoomRenderParameter) GetValueInt() int {
@@ -95,47 +128,51 @@ func (e *ZoomRenderParameter) SetValueInt(v int) int {
 		return v
 	}
 
+	old := e.Value
 	e.Value = v
+	e.NotifyChange(old, v)
 
-	rMin := e.Model.Params[0].GetValueFloat64()
-	iMin := e.Model.Params[1].GetValueFloat64()
-	rMax := e.Model.Params[2].GetValueFloat64()
-	//iMax := e.Model.Params[3].GetValueFloat64()
-	width := e.Model.Params[5].GetValueInt()
-	//height := e.Model.Params[6].GetValueInt()
-	mouseX := e.Model.Params[10].GetValueFloat64()
-	mouseY := e.Model.Params[11].GetValueFloat64()
+	rMin := e.Config.Left
+	iMin := e.Config.Top
+	rMax := e.Config.Right
+	iMax := e.Config.Bottom
+	width := e.Config.Width
+	height := e.Config.Height
+	mouseX := e.Config.MouseX
+	mouseY := e.Config.MouseY
 
 	zwidth := rMax - rMin
-	//zheight := iMax - iMin
+	zheight := iMax - iMin
 	nzwidth := zwidth * dz
-	//nzheight := zheight * dz
+	nzheight := zheight * dz
 
 	cx := mouseX / float64(width)
-	cy := mouseY / float64(width)
+	cy := mouseY / float64(height)
 
 	nleft := rMin - ((nzwidth - zwidth) * cx)
 	nright := nleft + nzwidth
-	ntop := iMin - ((nzwidth - zwidth) * cy)
-	nbottom := ntop + nzwidth
-	e.Model.Params[0].SetValueFloat64(nleft)
-	e.Model.Params[1].SetValueFloat64(ntop)
-	e.Model.Params[2].SetValueFloat64(nright)
-	e.Model.Params[3].SetValueFloat64(nbottom)
-	e.Model.Params[4].SetValueInt(100 + int(math.Pow(1.1, float64(v))))
-
-	e.Model.RequestPaint()
+	ntop := iMin - ((nzheight - zheight) * cy)
+	nbottom := ntop + nzheight
+	e.Model.GetParameter("left").SetValueFloat64(nleft)
+	e.Model.GetParameter("top").SetValueFloat64(ntop)
+	e.Model.GetParameter("right").SetValueFloat64(nright)
+	e.Model.GetParameter("bottom").SetValueFloat64(nbottom)
+
+	if e.Model.RequestPaint != nil {
+		e.Model.RequestPaint()
+	}
 
 	return e.Value
 }
 
-func NewZoomRP(name string, value int, m *MandelModel) *ZoomRenderParameter {
+func NewZoomRP(name string, value int, m *MandelModel, c *MandelConfig) *ZoomRenderParameter {
 	return &ZoomRenderParameter{
 		EmptyParameter: rv.EmptyParameter{
 			Name: name,
 			Type: "int",
 		},
-		Value: value,
-		Model: m,
+		Value:  value,
+		Model:  m,
+		Config: c,
 	}
 }
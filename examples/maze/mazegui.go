@@ -5,9 +5,9 @@
 package main
 
 import (
-	"fmt"
 	"image"
 	"image/color"
+	"log"
 	"math/rand"
 	"time"
 
@@ -19,27 +19,48 @@ import (
 
 type ff float64
 
+// MazeConfig holds the parameters of the maze, bound to the sidebar by
+// BindStruct. Any change, including resizing the window, builds a new maze.
+type MazeConfig struct {
+	Width      int `rv:"width,hide"`
+	Height     int `rv:"height,hide"`
+	Page       int `rv:"page"`
+	LineWidth  int `rv:"linewidth,group=Drawing" label:"Line width" unit:"px" desc:"Thickness of the walls in pixels"`
+	CellWidth  int `rv:"cellwidth,group=Drawing" label:"Cell width" unit:"px" desc:"Size of each cell in pixels"`
+	MazeWidth  int `rv:"mazewidth,group=Maze" label:"Width" unit:"cells" desc:"Number of cells across"`
+	MazeHeight int `rv:"mazeheight,group=Maze" label:"Height" unit:"cells" desc:"Number of cells down"`
+}
+
 func main() {
-	sig := ""
+	var last MazeConfig
 	rand.Seed(time.Now().UnixNano())
 	m := rv.NewBasicRenderModel()
-	m.AddParameters(
-		rv.SetHints(rv.HINT_HIDE,
-			rv.NewIntRP("width", 0),
-			rv.NewIntRP("height", 0),
-		)...)
-	m.AddParameters(
-		rv.NewIntRP("page", 0),
-		rv.NewIntRP("linewidth", 1),
-		rv.NewIntRP("cellwidth", 5),
-		rv.NewIntRP("mazewidth", 100),
-		rv.NewIntRP("mazeheight", 100))
-	m.InnerRender = func() {
-		s := GetSignature(m)
-		if sig != s {
-			z := NewDepthFirstMaze(m.Params[5].GetValueInt(), m.Params[6].GetValueInt())
-			m.Img = RenderMaze(m, z)
-			sig = s
+	cfg := &MazeConfig{
+		LineWidth:  1,
+		CellWidth:  5,
+		MazeWidth:  100,
+		MazeHeight: 100,
+	}
+	if err := m.BindStruct(cfg); err != nil {
+		log.Fatal(err)
+	}
+	m.InnerRender = func(s rv.Snapshot) {
+		c := MazeConfig{
+			Width:      s.Int("width"),
+			Height:     s.Int("height"),
+			Page:       s.Int("page"),
+			LineWidth:  s.Int("linewidth"),
+			CellWidth:  s.Int("cellwidth"),
+			MazeWidth:  s.Int("mazewidth"),
+			MazeHeight: s.Int("mazeheight"),
+		}
+		if c != last {
+			z := NewDepthFirstMaze(c.MazeWidth, c.MazeHeight)
+			img := RenderMaze(c, z)
+			m.Lock()
+			m.Img = img
+			m.Unlock()
+			last = c
 			m.RequestPaint()
 		}
 	}
@@ -48,33 +69,13 @@ func main() {
 	driver.Main(m)
 }
 
-func GetSignature(r *rv.BasicRenderModel) string {
-	s := ""
-	for i := 0; i < len(r.Params); i++ {
-		p := r.Params[i]
-		switch p.GetType() {
-		case "int":
-			s = fmt.Sprintf("%s%v", s, p.GetValueInt())
-		case "uint32":
-			s = fmt.Sprintf("%s%v", s, p.GetValueUInt32())
-		case "float64":
-			s = fmt.Sprintf("%s%v", s, p.GetValueFloat64())
-		case "complex128":
-			s = fmt.Sprintf("%s%v", s, p.GetValueComplex128())
-		case "string":
-			s = fmt.Sprintf("%s%v", s, p.GetValueString())
-		}
-	}
-	return s
-}
-
-func RenderMaze(r rv.RenderModel, m *Maze) image.Image {
+func RenderMaze(c MazeConfig, m *Maze) image.Image {
 	//	w := m.width
 	//	h := m.height
-	mw := r.GetParameter("mazewidth").GetValueInt()
-	mh := r.GetParameter("mazeheight").GetValueInt()
-	lw := r.GetParameter("linewidth").GetValueInt()
-	cw := r.GetParameter("cellwidth").GetValueInt()
+	mw := c.MazeWidth
+	mh := c.MazeHeight
+	lw := c.LineWidth
+	cw := c.CellWidth
 
 	iw := (mw * cw) + ((mw + 2) * lw)
 	ih := (mh * cw) + ((mh + 2) * lw)
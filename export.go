@@ -0,0 +1,168 @@
+// Copyright 2020 Howard C. Shaw III. All rights reserved.
+// Use of this source code is governed by the MIT-license
+// as defined in the LICENSE file.
+
+package renderview
+
+import (
+	"fmt"
+	"image"
+	"image/color/palette"
+	"image/draw"
+	"image/gif"
+	"image/png"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// SyncRenderer is implemented by models that can render and wait for the
+// result, as BasicRenderModel does. Export renders through it when the
+// model has it, and through Render otherwise.
+type SyncRenderer interface {
+	RenderSync() image.Image
+}
+
+// headless prepares m for rendering without a view, recording its
+// parameters, and returns a function putting them back
+func headless(m RenderModel) func() {
+	m.Lock()
+	defer m.Unlock()
+	saved := parameterState(m)
+	paint := m.GetRequestPaintFunc()
+	if paint == nil {
+		// the examples call RequestPaint when a render finishes
+		m.SetRequestPaintFunc(func() {})
+	}
+	return func() {
+		m.Lock()
+		defer m.Unlock()
+		applyState(m, saved)
+		if paint == nil {
+			m.SetRequestPaintFunc(nil)
+		}
+	}
+}
+
+// renderNow renders the current parameters of m, waiting for the image
+// if m is a SyncRenderer
+func renderNow(m RenderModel) (image.Image, error) {
+	var img image.Image
+	if s, ok := m.(SyncRenderer); ok {
+		img = s.RenderSync()
+	} else {
+		img = m.Render()
+	}
+	if img == nil {
+		return nil, fmt.Errorf("the model rendered no image")
+	}
+	return img, nil
+}
+
+// RenderFrames sets the model to each frame of the timeline in turn,
+// FPS frames a second, and hands the rendered image to f, stopping at
+// the first error. The parameters are restored afterwards. The frames
+// are the size of the model's width and height parameters, so set them
+// first when there is no view to do so.
+func (t *Timeline) RenderFrames(f func(i int, img image.Image) error) error {
+	frames := t.Frames()
+	if frames == 0 {
+		return fmt.Errorf("the timeline has no keyframes")
+	}
+	if err := checkFPS(t.frameRate()); err != nil {
+		return err
+	}
+	t.Stop()
+	m := t.model
+	defer headless(m)()
+
+	t.Lock()
+	start, fps := t.keys[0].Time, t.FPS
+	t.Unlock()
+	for i := 0; i < frames; i++ {
+		if err := t.ApplyAt(start + float64(i)/fps); err != nil {
+			return err
+		}
+		img, err := renderNow(m)
+		if err == nil {
+			err = f(i, img)
+		}
+		if err != nil {
+			return fmt.Errorf("frame %d: %v", i, err)
+		}
+	}
+	return nil
+}
+
+// ExportPNG writes the frames of the timeline as PNG files, numbered
+// from 0 by formatting pattern, for example "frame%04d.png"
+func (t *Timeline) ExportPNG(pattern string) error {
+	return t.RenderFrames(func(i int, img image.Image) error {
+		return writePNG(fmt.Sprintf(pattern, i), img)
+	})
+}
+
+// writePNG writes img to the named file as a PNG
+func writePNG(name string, img image.Image) error {
+	f, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	err = png.Encode(f, img)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// ExportGIF writes the frames of the timeline as an animated GIF to
+// the named file, dithered to the Plan 9 palette
+func (t *Timeline) ExportGIF(name string) error {
+	delay := int(math.Round(100 / t.frameRate()))
+	anim := &gif.GIF{}
+	err := t.RenderFrames(func(i int, img image.Image) error {
+		p := image.NewPaletted(img.Bounds(), palette.Plan9)
+		draw.FloydSteinberg.Draw(p, p.Rect, img, img.Bounds().Min)
+		anim.Image = append(anim.Image, p)
+		anim.Delay = append(anim.Delay, delay)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	f, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	err = gif.EncodeAll(f, anim)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// Export writes the timeline as an animated GIF when name ends in .gif,
+// and otherwise as a PNG sequence. A name without a % verb is numbered
+// before its extension, so frame.png gives frame0000.png, frame0001.png
+// and so on.
+func (t *Timeline) Export(name string) error {
+	ext := filepath.Ext(name)
+	if strings.EqualFold(ext, ".gif") {
+		return t.ExportGIF(name)
+	}
+	if !strings.Contains(name, "%") {
+		if ext == "" {
+			ext = ".png"
+		}
+		name = strings.TrimSuffix(name, filepath.Ext(name)) + "%04d" + ext
+	}
+	return t.ExportPNG(name)
+}
+
+// DefaultAnimationFile returns the file name suggested for exporting an
+// animation, named after the program
+func DefaultAnimationFile() string {
+	name := strings.TrimSuffix(filepath.Base(os.Args[0]), filepath.Ext(os.Args[0]))
+	return name + ".gif"
+}
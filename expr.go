@@ -0,0 +1,357 @@
+// Copyright 2020 Howard C. Shaw III. All rights reserved.
+// Use of this source code is governed by the MIT-license
+// as defined in the LICENSE file.
+
+package renderview
+
+import (
+	"fmt"
+	"math"
+	"math/cmplx"
+	"strconv"
+	"strings"
+)
+
+// exprConstants are the names EvalExpression knows
+var exprConstants = map[string]complex128{
+	"pi":  math.Pi,
+	"tau": 2 * math.Pi,
+	"e":   math.E,
+	"phi": math.Phi,
+	"i":   1i,
+	"inf": complex(math.Inf(1), 0),
+}
+
+// exprFunction applies r to real arguments inside its domain, and c to any other
+type exprFunction struct {
+	r      func(float64) float64
+	domain func(float64) bool
+	c      func(complex128) complex128
+}
+
+func anyReal(float64) bool { return true }
+
+// exprFunctions are the functions EvalExpression knows, each of one argument
+var exprFunctions = map[string]exprFunction{
+	"sqrt":  {math.Sqrt, func(x float64) bool { return x >= 0 }, cmplx.Sqrt},
+	"exp":   {math.Exp, anyReal, cmplx.Exp},
+	"log":   {math.Log, func(x float64) bool { return x > 0 }, cmplx.Log},
+	"ln":    {math.Log, func(x float64) bool { return x > 0 }, cmplx.Log},
+	"log10": {math.Log10, func(x float64) bool { return x > 0 }, cmplx.Log10},
+	"log2":  {math.Log2, func(x float64) bool { return x > 0 }, func(z complex128) complex128 { return cmplx.Log(z) / math.Ln2 }},
+	"sin":   {math.Sin, anyReal, cmplx.Sin},
+	"cos":   {math.Cos, anyReal, cmplx.Cos},
+	"tan":   {math.Tan, anyReal, cmplx.Tan},
+	"asin":  {math.Asin, func(x float64) bool { return x >= -1 && x <= 1 }, cmplx.Asin},
+	"acos":  {math.Acos, func(x float64) bool { return x >= -1 && x <= 1 }, cmplx.Acos},
+	"atan":  {math.Atan, anyReal, cmplx.Atan},
+	"sinh":  {math.Sinh, anyReal, cmplx.Sinh},
+	"cosh":  {math.Cosh, anyReal, cmplx.Cosh},
+	"tanh":  {math.Tanh, anyReal, cmplx.Tanh},
+	"abs":   {math.Abs, anyReal, func(z complex128) complex128 { return complex(cmplx.Abs(z), 0) }},
+	"floor": {math.Floor, anyReal, nil},
+	"ceil":  {math.Ceil, anyReal, nil},
+	"round": {math.Round, anyReal, nil},
+	"re":    {nil, nil, func(z complex128) complex128 { return complex(real(z), 0) }},
+	"im":    {nil, nil, func(z complex128) complex128 { return complex(imag(z), 0) }},
+	"arg":   {nil, nil, func(z complex128) complex128 { return complex(cmplx.Phase(z), 0) }},
+	"conj":  {nil, nil, cmplx.Conj},
+}
+
+// EvalExpression evaluates an arithmetic expression over the complex
+// numbers, such as pi/3, 2^-20, 1e3*4 or sqrt(2)*(1-2i). It understands
+// + - * / and ^ with the usual precedence, parentheses, numbers with an
+// optional i suffix for imaginary parts, the constants pi, tau, e, phi, i
+// and inf, and the functions sqrt, exp, log (or ln), log10, log2, sin,
+// cos, tan, asin, acos, atan, sinh, cosh, tanh, abs, floor, ceil, round,
+// re, im, arg and conj. Real arguments stay real wherever the function
+// is defined for them, so sqrt(4) is exactly 2.
+func EvalExpression(s string) (complex128, error) {
+	p := &exprParser{s: s}
+	v, err := p.expr()
+	if err != nil {
+		return 0, err
+	}
+	if p.skip(); p.pos < len(p.s) {
+		return 0, p.errorf("unexpected %q", p.s[p.pos:])
+	}
+	return v, nil
+}
+
+// ParseFloatExpression parses v as a number, or failing that evaluates
+// it with EvalExpression, rejecting results with an imaginary part and
+// results that are not a number, like 0/0
+func ParseFloatExpression(v string) (float64, error) {
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		z, err := EvalExpression(v)
+		if err != nil {
+			return 0, err
+		}
+		if imag(z) != 0 {
+			return 0, fmt.Errorf("%v is not real", FormatComplex(z))
+		}
+		f = real(z)
+	}
+	if math.IsNaN(f) {
+		return 0, fmt.Errorf("%v is not a number", v)
+	}
+	return f, nil
+}
+
+// ParseIntExpression parses v as an integer, or failing that evaluates
+// it with EvalExpression, rejecting results that are not whole numbers
+func ParseIntExpression(v string) (int, error) {
+	if n, err := strconv.Atoi(v); err == nil {
+		return n, nil
+	}
+	f, err := ParseFloatExpression(v)
+	if err != nil {
+		return 0, err
+	}
+	if f != math.Trunc(f) {
+		return 0, fmt.Errorf("%v is not a whole number", f)
+	}
+	if f < math.MinInt64 || f >= math.MaxInt64 || int64(int(f)) != int64(f) {
+		return 0, strconv.ErrRange
+	}
+	return int(f), nil
+}
+
+// ParseComplexExpression evaluates v with EvalExpression, so 1+2 is 3,
+// accepting the older a,b pair form through ParseComplex
+func ParseComplexExpression(v string) (complex128, error) {
+	if strings.Contains(v, ",") {
+		return ParseComplex(v)
+	}
+	return EvalExpression(v)
+}
+
+// FormatComplex writes z as a+bi, or just a when it is real
+func FormatComplex(z complex128) string {
+	if imag(z) == 0 {
+		return strconv.FormatFloat(real(z), 'g', -1, 64)
+	}
+	return fmt.Sprintf("%g%+gi", real(z), imag(z))
+}
+
+// exprParser is a recursive descent parser evaluating as it goes
+type exprParser struct {
+	s   string
+	pos int
+}
+
+func (p *exprParser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("at %d: %v", p.pos+1, fmt.Sprintf(format, args...))
+}
+
+func (p *exprParser) skip() {
+	for p.pos < len(p.s) && (p.s[p.pos] == ' ' || p.s[p.pos] == '\t' || p.s[p.pos] == '\n') {
+		p.pos++
+	}
+}
+
+// next skips space and returns the next byte without consuming it, or 0 at the end
+func (p *exprParser) next() byte {
+	p.skip()
+	if p.pos < len(p.s) {
+		return p.s[p.pos]
+	}
+	return 0
+}
+
+// expr = term { ("+" | "-") term }
+func (p *exprParser) expr() (complex128, error) {
+	v, err := p.term()
+	if err != nil {
+		return 0, err
+	}
+	for {
+		op := p.next()
+		if op != '+' && op != '-' {
+			return v, nil
+		}
+		p.pos++
+		w, err := p.term()
+		if err != nil {
+			return 0, err
+		}
+		if op == '+' {
+			v += w
+		} else {
+			v -= w
+		}
+	}
+}
+
+// term = unary { ("*" | "/") unary }
+func (p *exprParser) term() (complex128, error) {
+	v, err := p.unary()
+	if err != nil {
+		return 0, err
+	}
+	for {
+		op := p.next()
+		if op != '*' && op != '/' {
+			return v, nil
+		}
+		p.pos++
+		w, err := p.unary()
+		if err != nil {
+			return 0, err
+		}
+		if op == '*' {
+			v *= w
+		} else if w == 0 {
+			return 0, fmt.Errorf("division by zero")
+		} else if imag(v) == 0 && imag(w) == 0 {
+			v = complex(real(v)/real(w), 0)
+		} else {
+			v /= w
+		}
+	}
+}
+
+// unary = ("+" | "-") unary | power
+func (p *exprParser) unary() (complex128, error) {
+	switch p.next() {
+	case '+':
+		p.pos++
+		return p.unary()
+	case '-':
+		p.pos++
+		v, err := p.unary()
+		if imag(v) == 0 {
+			// keep the imaginary part +0, so sqrt(-1) is i rather than -i
+			return complex(-real(v), 0), err
+		}
+		return -v, err
+	}
+	return p.power()
+}
+
+// power = primary [ "^" unary ], so 2^-1 is a half and 2^3^2 is 2^9
+func (p *exprParser) power() (complex128, error) {
+	v, err := p.primary()
+	if err != nil {
+		return 0, err
+	}
+	if p.next() != '^' {
+		return v, nil
+	}
+	p.pos++
+	w, err := p.unary()
+	if err != nil {
+		return 0, err
+	}
+	if imag(w) == 0 && real(w) == math.Trunc(real(w)) && math.Abs(real(w)) <= 64 {
+		// whole powers by multiplication, so (1+i)^2 is exactly 2i
+		n := int(real(w))
+		if imag(v) == 0 {
+			return complex(math.Pow(real(v), real(w)), 0), nil
+		}
+		r := complex(1, 0)
+		for i := 0; i < n || i < -n; i++ {
+			r *= v
+		}
+		if n < 0 {
+			r = 1 / r
+		}
+		return r, nil
+	}
+	if imag(v) == 0 && imag(w) == 0 && real(v) >= 0 {
+		return complex(math.Pow(real(v), real(w)), 0), nil
+	}
+	return cmplx.Pow(v, w), nil
+}
+
+// primary = number | constant | function "(" expr ")" | "(" expr ")"
+func (p *exprParser) primary() (complex128, error) {
+	c := p.next()
+	switch {
+	case c == 0:
+		return 0, p.errorf("expression ends too soon")
+	case c == '(':
+		p.pos++
+		v, err := p.expr()
+		if err != nil {
+			return 0, err
+		}
+		if p.next() != ')' {
+			return 0, p.errorf("missing )")
+		}
+		p.pos++
+		return v, nil
+	case c == '.' || isDigit(c):
+		return p.number()
+	case isLetter(c):
+		start := p.pos
+		for p.pos < len(p.s) && (isLetter(p.s[p.pos]) || isDigit(p.s[p.pos])) {
+			p.pos++
+		}
+		name := strings.ToLower(p.s[start:p.pos])
+		if f, ok := exprFunctions[name]; ok {
+			if p.next() != '(' {
+				return 0, p.errorf("%v needs an argument in parentheses", name)
+			}
+			v, err := p.primary()
+			if err != nil {
+				return 0, err
+			}
+			return f.apply(name, v)
+		}
+		if v, ok := exprConstants[name]; ok {
+			return v, nil
+		}
+		p.pos = start
+		return 0, p.errorf("unknown name %q", name)
+	}
+	return 0, p.errorf("unexpected %q", c)
+}
+
+// number reads a decimal number, with an optional exponent and i suffix
+func (p *exprParser) number() (complex128, error) {
+	start := p.pos
+	for p.pos < len(p.s) && (isDigit(p.s[p.pos]) || p.s[p.pos] == '.') {
+		p.pos++
+	}
+	if p.pos < len(p.s) && (p.s[p.pos] == 'e' || p.s[p.pos] == 'E') {
+		q := p.pos + 1
+		if q < len(p.s) && (p.s[q] == '+' || p.s[q] == '-') {
+			q++
+		}
+		if q < len(p.s) && isDigit(p.s[q]) {
+			for p.pos = q; p.pos < len(p.s) && isDigit(p.s[p.pos]); p.pos++ {
+			}
+		}
+	}
+	text := p.s[start:p.pos]
+	f, err := strconv.ParseFloat(text, 64)
+	if err != nil {
+		p.pos = start
+		return 0, p.errorf("bad number %q", text)
+	}
+	if p.pos < len(p.s) && p.s[p.pos] == 'i' && (p.pos+1 == len(p.s) || !isLetter(p.s[p.pos+1]) && !isDigit(p.s[p.pos+1])) {
+		p.pos++
+		return complex(0, f), nil
+	}
+	return complex(f, 0), nil
+}
+
+func (f exprFunction) apply(name string, v complex128) (complex128, error) {
+	if f.r != nil && imag(v) == 0 && f.domain(real(v)) {
+		return complex(f.r(real(v)), 0), nil
+	}
+	if f.c == nil {
+		return 0, fmt.Errorf("%v needs a real argument", name)
+	}
+	return f.c(v), nil
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isLetter(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c == '_'
+}
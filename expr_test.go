@@ -0,0 +1,55 @@
+// Copyright 2020 Howard C. Shaw III. All rights reserved.
+// Use of this source code is governed by the MIT-license
+// as defined in the LICENSE file.
+
+package renderview
+
+import "testing"
+
+func TestParseComplexExpression(t *testing.T) {
+	tests := []struct {
+		in   string
+		want complex128
+	}{
+		{"1+2", 3},
+		{"1+1+1", 3},
+		{"2*(1+1i)", 2 + 2i},
+		{"1+2i", 1 + 2i},
+		{"1-2i", 1 - 2i},
+		{"-0.5", -0.5},
+		{"1.5e+3", 1500},
+		{"1,2", 1 + 2i},
+		{"(-1e-05-2i)", -1e-05 - 2i},
+	}
+	for _, tt := range tests {
+		got, err := ParseComplexExpression(tt.in)
+		if err != nil {
+			t.Errorf("ParseComplexExpression(%q): %v", tt.in, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseComplexExpression(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestParseFloatExpressionRejectsNaN(t *testing.T) {
+	for _, in := range []string{"NaN", "inf-inf", "0/0"} {
+		if f, err := ParseFloatExpression(in); err == nil {
+			t.Errorf("ParseFloatExpression(%q) = %v, want an error", in, f)
+		}
+	}
+}
+
+func TestSetBoundedFloatRejectsInf(t *testing.T) {
+	p := NewFloat64RP("x", 1)
+	p.Bounded, p.Min, p.Max = true, 0, 10
+	for _, in := range []string{"inf", "-inf", "1/0"} {
+		if err := SetParameterValueFromString(p, in); err == nil {
+			t.Errorf("SetParameterValueFromString(%q) accepted it", in)
+		}
+	}
+	if p.Value != 1 {
+		t.Errorf("Value = %v after rejected input, want 1", p.Value)
+	}
+}
@@ -1,14 +1,21 @@
 module github.com/TheGrum/renderview
 
-go 1.13
+go 1.18
 
 require (
 	gioui.org v0.0.0-20200210173153-f38dbfca544c
 	github.com/gotk3/gotk3 v0.0.0-20200210190119-513f671252e2
 	github.com/llgcode/draw2d v0.0.0-20200110163050-b96d8208fcfc
 	github.com/mattn/go-gtk v0.0.0-20191030024613-af2e013261f5
-	github.com/mattn/go-pointer v0.0.0-20190911064623-a0a44394634f // indirect
 	golang.org/x/exp v0.0.0-20200207192155-f17229e696bd
 	golang.org/x/image v0.0.0-20190802002840-cff245a6509b
 	golang.org/x/mobile v0.0.0-20200205170228-0df4eb238546
 )
+
+require (
+	github.com/BurntSushi/xgb v0.0.0-20160522181843-27f122750802 // indirect
+	github.com/golang/freetype v0.0.0-20170609003504-e2365dfdc4a0 // indirect
+	github.com/mattn/go-pointer v0.0.0-20190911064623-a0a44394634f // indirect
+	golang.org/x/sys v0.0.0-20200124204421-9fbb57f87de9 // indirect
+	golang.org/x/text v0.3.0 // indirect
+)
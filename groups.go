@@ -0,0 +1,69 @@
+// Copyright 2020 Howard C. Shaw III. All rights reserved.
+// Use of this source code is governed by the MIT-license
+// as defined in the LICENSE file.
+
+package renderview
+
+import "sort"
+
+// ParameterGroup is a named section of the sidebar with the names of its
+// parameters in display order. Parameters without a group belong to the
+// group named "".
+type ParameterGroup struct {
+	Name   string
+	Params []string
+}
+
+// Title returns the header the drivers show for the group
+func (g ParameterGroup) Title() string {
+	if g.Name == "" {
+		return "Parameters"
+	}
+	return g.Name
+}
+
+// SetGroup puts params in group, ordered as they are passed
+func SetGroup(group string, params ...RenderParameter) []RenderParameter {
+	for i, p := range params {
+		p.SetGroup(group)
+		p.SetOrder(i)
+	}
+
+	return params
+}
+
+// GroupParameters sorts the named parameters of m into groups. Groups
+// listed in order come first, in that order, and the rest follow in the
+// order their first parameter appears in names. Within a group the
+// parameters are sorted by GetOrder, keeping the order of names among equals.
+func GroupParameters(m RenderModel, names []string, order []string) []ParameterGroup {
+	rank := make(map[string]int)
+	for i, g := range order {
+		rank[g] = i - len(order)
+	}
+	index := make(map[string]int)
+	var groups []ParameterGroup
+	for _, name := range names {
+		g := m.GetParameter(name).GetGroup()
+		i, ok := index[g]
+		if !ok {
+			i = len(groups)
+			index[g] = i
+			groups = append(groups, ParameterGroup{Name: g})
+			if _, ok := rank[g]; !ok {
+				rank[g] = i
+			}
+		}
+		groups[i].Params = append(groups[i].Params, name)
+	}
+	for _, g := range groups {
+		params := g.Params
+		sort.SliceStable(params, func(i, j int) bool {
+			return m.GetParameter(params[i]).GetOrder() < m.GetParameter(params[j]).GetOrder()
+		})
+	}
+	sort.SliceStable(groups, func(i, j int) bool {
+		return rank[groups[i].Name] < rank[groups[j].Name]
+	})
+	return groups
+}
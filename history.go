@@ -0,0 +1,134 @@
+// Copyright 2020 Howard C. Shaw III. All rights reserved.
+// Use of this source code is governed by the MIT-license
+// as defined in the LICENSE file.
+
+package renderview
+
+import (
+	"sync"
+	"time"
+)
+
+// History keeps earlier states of a model's parameters for Undo and Redo.
+// The window size and mouse position are not part of the state.
+//
+// Drivers call Checkpoint before each change the user makes. Checkpoints
+// with the same key following each other within Coalesce, such as the
+// steps of a drag or a burst of wheel turns, record a single state.
+type History struct {
+	sync.Mutex
+
+	// Coalesce is the longest pause between checkpoints that are merged
+	Coalesce time.Duration
+	// Limit caps the number of states kept for Undo
+	Limit int
+
+	model    RenderModel
+	undo     [][]SavedParameter
+	redo     [][]SavedParameter
+	lastKey  string
+	lastTime time.Time
+}
+
+// NewHistory creates an empty history for m
+func NewHistory(m RenderModel) *History {
+	return &History{
+		Coalesce: time.Second,
+		Limit:    100,
+		model:    m,
+	}
+}
+
+func (h *History) state() []SavedParameter {
+	h.model.Lock()
+	defer h.model.Unlock()
+	return parameterState(h.model)
+}
+
+func (h *History) apply(s []SavedParameter) {
+	h.model.Lock()
+	defer h.model.Unlock()
+	// the values came from the parameters themselves, so they parse
+	applyState(h.model, s)
+}
+
+func sameState(a []SavedParameter, b []SavedParameter) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// Checkpoint records the current state before a change identified by key,
+// for example "pan", "zoom" or the name of a parameter. It records nothing
+// if the previous checkpoint had the same key and was less than Coalesce ago.
+func (h *History) Checkpoint(key string) {
+	h.Lock()
+	defer h.Unlock()
+
+	now := time.Now()
+	coalesce := key == h.lastKey && now.Sub(h.lastTime) < h.Coalesce
+	h.lastKey = key
+	h.lastTime = now
+	if coalesce {
+		return
+	}
+	h.redo = nil
+	s := h.state()
+	if len(h.undo) > 0 && sameState(h.undo[len(h.undo)-1], s) {
+		return
+	}
+	h.undo = append(h.undo, s)
+	if h.Limit > 0 && len(h.undo) > h.Limit {
+		h.undo = h.undo[len(h.undo)-h.Limit:]
+	}
+}
+
+// step pops states from one stack until one differs from the current
+// state, applies it and pushes the current state on the other stack
+func (h *History) step(from *[][]SavedParameter, to *[][]SavedParameter) bool {
+	h.Lock()
+	defer h.Unlock()
+
+	h.lastKey = ""
+	current := h.state()
+	for len(*from) > 0 {
+		s := (*from)[len(*from)-1]
+		*from = (*from)[:len(*from)-1]
+		if !sameState(s, current) {
+			*to = append(*to, current)
+			h.apply(s)
+			return true
+		}
+	}
+	return false
+}
+
+// Undo restores the state before the last change, reporting whether there was one
+func (h *History) Undo() bool {
+	return h.step(&h.undo, &h.redo)
+}
+
+// Redo reapplies the last change undone, reporting whether there was one
+func (h *History) Redo() bool {
+	return h.step(&h.redo, &h.undo)
+}
+
+// CanUndo reports whether Undo has a state to restore
+func (h *History) CanUndo() bool {
+	h.Lock()
+	defer h.Unlock()
+	return len(h.undo) > 0
+}
+
+// CanRedo reports whether Redo has a state to restore
+func (h *History) CanRedo() bool {
+	h.Lock()
+	defer h.Unlock()
+	return len(h.redo) > 0
+}
@@ -0,0 +1,235 @@
+// Copyright 2020 Howard C. Shaw III. All rights reserved.
+// Use of this source code is governed by the MIT-license
+// as defined in the LICENSE file.
+
+package renderview
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ListElement lists the element types of list parameters
+type ListElement interface {
+	int | float64 | string
+}
+
+// ListRenderParameter holds a list of ints, float64s or strings, with
+// the type "[]int", "[]float64" or "[]string". Its string form, used by
+// GetValueString and SetValueString, is the items separated by commas
+// (see FormatList). The drivers show it as a list editor.
+type ListRenderParameter[T ListElement] struct {
+	EmptyParameter
+
+	Value []T
+}
+
+// GetValueList returns a copy of the list
+func (e *ListRenderParameter[T]) GetValueList() []T {
+	return append([]T(nil), e.Value...)
+}
+
+// SetValueList stores a copy of v and returns the list
+func (e *ListRenderParameter[T]) SetValueList(v []T) []T {
+	old := e.Value
+	e.Value = append([]T(nil), v...)
+	e.NotifyChange(old, e.GetValueList())
+	return e.GetValueList()
+}
+
+func (e *ListRenderParameter[T]) GetValueString() string {
+	return FormatList(formatList(e.Value))
+}
+
+// SetValueString parses v as a list, leaving the list as it was if any
+// item does not parse, and returns the list's string form
+func (e *ListRenderParameter[T]) SetValueString(v string) string {
+	if l, err := parseList[T](v); err == nil {
+		e.SetValueList(l)
+	}
+	return e.GetValueString()
+}
+
+func NewIntListRP(name string, value []int) *ListRenderParameter[int] {
+	return newListRP(name, "[]int", value)
+}
+
+func NewFloat64ListRP(name string, value []float64) *ListRenderParameter[float64] {
+	return newListRP(name, "[]float64", value)
+}
+
+func NewStringListRP(name string, value []string) *ListRenderParameter[string] {
+	return newListRP(name, "[]string", value)
+}
+
+func newListRP[T ListElement](name string, typ string, value []T) *ListRenderParameter[T] {
+	return &ListRenderParameter[T]{
+		EmptyParameter: EmptyParameter{
+			Name: name,
+			Type: typ,
+		},
+		Value: append([]T(nil), value...),
+	}
+}
+
+// IsList reports whether p holds a list
+func IsList(p RenderParameter) bool {
+	return strings.HasPrefix(p.GetType(), "[]")
+}
+
+// GetListItems returns the items of a list parameter as text, for editing
+func GetListItems(p RenderParameter) []string {
+	items, _ := ParseList(p.GetValueString())
+	return items
+}
+
+// SetListItems sets a list parameter from the text of its items, as
+// SetParameterValueFromString would from the whole list
+func SetListItems(p RenderParameter, items []string) error {
+	return SetParameterValueFromString(p, FormatList(items))
+}
+
+// NewListItem returns the text of an item to add to the end of items, a
+// copy of the last one or else an empty string or 0
+func NewListItem(p RenderParameter, items []string) string {
+	if len(items) > 0 {
+		return items[len(items)-1]
+	}
+	if p.GetType() == "[]string" {
+		return ""
+	}
+	return "0"
+}
+
+// FormatList joins items with commas, quoting any that are empty or
+// hold a comma, a quote or surrounding spaces, so ParseList recovers them
+func FormatList(items []string) string {
+	quoted := make([]string, len(items))
+	for i, s := range items {
+		if s == "" || strings.ContainsAny(s, `,"`) || strings.TrimSpace(s) != s {
+			s = strconv.Quote(s)
+		}
+		quoted[i] = s
+	}
+	return strings.Join(quoted, ", ")
+}
+
+// ParseList splits a list written by FormatList into its items. Text
+// holding only spaces is an empty list.
+func ParseList(s string) ([]string, error) {
+	var items []string
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return items, nil
+	}
+	for {
+		var item string
+		if strings.HasPrefix(s, `"`) {
+			q, err := strconv.QuotedPrefix(s)
+			if err != nil {
+				return nil, fmt.Errorf("unterminated quote in %v", s)
+			}
+			item, _ = strconv.Unquote(q)
+			s = strings.TrimSpace(s[len(q):])
+			if s != "" && s[0] != ',' {
+				return nil, fmt.Errorf("expected a comma after %v", q)
+			}
+		} else {
+			i := strings.IndexByte(s, ',')
+			if i < 0 {
+				i = len(s)
+			}
+			item = strings.TrimSpace(s[:i])
+			s = s[i:]
+		}
+		items = append(items, item)
+		if s == "" {
+			return items, nil
+		}
+		// skip the comma
+		s = strings.TrimSpace(s[1:])
+	}
+}
+
+// formatList writes each element of l as text
+func formatList[T ListElement](l []T) []string {
+	items := make([]string, len(l))
+	for i, v := range l {
+		switch v := any(v).(type) {
+		case int:
+			items[i] = strconv.Itoa(v)
+		case float64:
+			items[i] = strconv.FormatFloat(v, 'g', -1, 64)
+		case string:
+			items[i] = v
+		}
+	}
+	return items
+}
+
+// parseList parses the string form of a list of T, evaluating numbers
+// as expressions like the other numeric parameters
+func parseList[T ListElement](s string) ([]T, error) {
+	items, err := ParseList(s)
+	if err != nil {
+		return nil, err
+	}
+	l := make([]T, len(items))
+	for i, item := range items {
+		var v interface{}
+		switch any(l).(type) {
+		case []int:
+			v, err = ParseIntExpression(item)
+		case []float64:
+			v, err = ParseFloatExpression(item)
+		default:
+			v = item
+		}
+		if err != nil {
+			return nil, fmt.Errorf("item %d: %v", i+1, err)
+		}
+		l[i] = v.(T)
+	}
+	return l, nil
+}
+
+// parseListValue parses the string form of a list parameter of type typ
+// into its native slice type
+func parseListValue(typ string, s string) (interface{}, error) {
+	switch typ {
+	case "[]int":
+		return parseList[int](s)
+	case "[]float64":
+		return parseList[float64](s)
+	}
+	return parseList[string](s)
+}
+
+// sameValue compares two values of a parameter, including lists
+func sameValue(a interface{}, b interface{}) bool {
+	switch a := a.(type) {
+	case []int:
+		b, ok := b.([]int)
+		return ok && sameList(a, b)
+	case []float64:
+		b, ok := b.([]float64)
+		return ok && sameList(a, b)
+	case []string:
+		b, ok := b.([]string)
+		return ok && sameList(a, b)
+	}
+	return a == b
+}
+
+func sameList[T ListElement](a []T, b []T) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
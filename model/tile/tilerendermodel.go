@@ -78,29 +78,30 @@ func (m *TileRenderModel) GoRender() {
 	for {
 		select {
 		case <-m.RequestRender:
-			m.InnerRender()
+			m.InnerRender(m.Snapshot())
 		}
 	}
 }
 
-func (m *TileRenderModel) InnerRender() {
-
+// InnerRender draws the tiles covering the viewport held in s
+func (m *TileRenderModel) InnerRender(s rv.Snapshot) {
+	width, height := s.Int("width"), s.Int("height")
 	img, ok := m.Img.(*image.RGBA)
 	if img == nil || !ok {
-		i2 := image.NewRGBA(image.Rect(0, 0, m.width.GetValueInt(), m.height.GetValueInt()))
+		i2 := image.NewRGBA(image.Rect(0, 0, width, height))
 		img = i2
 		m.Img = img
 	} else {
 		b := img.Bounds()
-		if b.Dx() != m.width.GetValueInt() || b.Dy() != m.height.GetValueInt() {
-			i2 := image.NewRGBA(image.Rect(0, 0, m.width.GetValueInt(), m.height.GetValueInt()))
+		if b.Dx() != width || b.Dy() != height {
+			i2 := image.NewRGBA(image.Rect(0, 0, width, height))
 			// maybe do something here to copy/move the previous image?
 			img = i2
 			m.Img = img
 		}
 	}
-	a := LatLon{m.top.GetValueFloat64(), m.left.GetValueFloat64()}
-	b := LatLon{m.bottom.GetValueFloat64(), m.right.GetValueFloat64()}
+	a := LatLon{s.Float64("top"), s.Float64("left")}
+	b := LatLon{s.Float64("bottom"), s.Float64("right")}
 	c, d := m.mapper.TilesFromBounds(a, b, uint(img.Bounds().Dx()), uint(img.Bounds().Dy()))
 	modA, _ := m.mapper.BoundsFromTiles(c, d)
 	ca, cb := m.mapper.BoundsFromTiles(c, c)
@@ -0,0 +1,152 @@
+// Copyright 2020 Howard C. Shaw III. All rights reserved.
+// Use of this source code is governed by the MIT-license
+// as defined in the LICENSE file.
+
+package renderview
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+)
+
+// ParamType lists the value types a Param can hold
+type ParamType interface {
+	int | uint32 | float64 | complex128 | bool | string | color.RGBA | Point | []int | []float64 | []string
+}
+
+// Param is a RenderParameter with typed Get and Set, so reading a value
+// as the wrong type is a compile error rather than a silent zero. It
+// embeds the parameter it wraps, so it can be handed to AddParameters
+// and the drivers like any other.
+//
+// A numeric Param may wrap a parameter of any numeric type, converting
+// on the way in and out: a Param[float64] reads an int parameter as a
+// float64, and Set rounds to the nearest int.
+type Param[T ParamType] struct {
+	RenderParameter
+}
+
+// NewParam creates a parameter of the type matching T, as NewIntRP,
+// NewFloat64RP and the like would, wrapped in a Param
+func NewParam[T ParamType](name string, value T) *Param[T] {
+	var p RenderParameter
+	switch v := any(value).(type) {
+	case int:
+		p = NewIntRP(name, v)
+	case uint32:
+		p = NewUInt32RP(name, v)
+	case float64:
+		p = NewFloat64RP(name, v)
+	case complex128:
+		p = NewComplex128RP(name, v)
+	case bool:
+		p = NewBoolRP(name, v)
+	case string:
+		p = NewStringRP(name, v)
+	case color.RGBA:
+		p = NewColorRP(name, v)
+	case Point:
+		p = NewPointRP(name, v.X, v.Y)
+	case []int:
+		p = NewIntListRP(name, v)
+	case []float64:
+		p = NewFloat64ListRP(name, v)
+	case []string:
+		p = NewStringListRP(name, v)
+	}
+	return &Param[T]{p}
+}
+
+// ParamOf wraps p in a Param, failing unless p's values can be read as
+// a T: numbers as any numeric type, and other types only as themselves.
+// Parameters holding text, such as choices, are read as strings.
+func ParamOf[T ParamType](p RenderParameter) (*Param[T], error) {
+	var zero T
+	if paramKind(GetParameterValue(p)) != paramKind(zero) {
+		return nil, fmt.Errorf("renderview: cannot read %v parameter %v as %T", p.GetType(), p.GetName(), zero)
+	}
+	return &Param[T]{p}, nil
+}
+
+// GetParam finds the named parameter of m and wraps it with ParamOf
+func GetParam[T ParamType](m RenderModel, name string) (*Param[T], error) {
+	p := m.GetParameter(name)
+	if p.GetName() != name {
+		return nil, fmt.Errorf("renderview: no parameter named %q", name)
+	}
+	return ParamOf[T](p)
+}
+
+// Get returns the parameter's value as a T
+func (p *Param[T]) Get() T {
+	v := GetParameterValue(p.RenderParameter)
+	if t, ok := v.(T); ok {
+		return t
+	}
+	f := GetParameterValueAsFloat64(p.RenderParameter)
+	var r interface{}
+	switch any(*new(T)).(type) {
+	case int:
+		r = int(math.Round(f))
+	case uint32:
+		r = uint32(math.Round(f))
+	case float64:
+		r = f
+	case complex128:
+		r = complex(f, 0)
+	default:
+		return *new(T)
+	}
+	return r.(T)
+}
+
+// Set stores v, converting it to the parameter's own numeric type if
+// that differs from T, and returns the value as stored
+func (p *Param[T]) Set(v T) T {
+	if z, ok := any(v).(complex128); ok && p.GetType() != "complex128" {
+		SetParameterValueFromFloat64(p.RenderParameter, real(z))
+	} else {
+		SetParameterValue(p.RenderParameter, v)
+	}
+	return p.Get()
+}
+
+// Add offsets a numeric parameter by d and returns the new value
+func (p *Param[T]) Add(d T) T {
+	var r interface{}
+	switch v := any(p.Get()).(type) {
+	case int:
+		r = v + any(d).(int)
+	case uint32:
+		r = v + any(d).(uint32)
+	case float64:
+		r = v + any(d).(float64)
+	case complex128:
+		r = v + any(d).(complex128)
+	default:
+		return p.Get()
+	}
+	return p.Set(r.(T))
+}
+
+// paramKind groups values that a Param can convert between
+func paramKind(v interface{}) string {
+	switch v.(type) {
+	case int, uint32, float64, complex128:
+		return "number"
+	case bool:
+		return "bool"
+	case color.RGBA:
+		return "color"
+	case Point:
+		return "point"
+	case []int:
+		return "[]int"
+	case []float64:
+		return "[]float64"
+	case []string:
+		return "[]string"
+	}
+	return "string"
+}
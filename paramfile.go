@@ -0,0 +1,203 @@
+// Copyright 2020 Howard C. Shaw III. All rights reserved.
+// Use of this source code is governed by the MIT-license
+// as defined in the LICENSE file.
+
+package renderview
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// SavedParameter is the form in which SaveParameters writes each parameter
+type SavedParameter struct {
+	Name  string `json:"name"`
+	Type  string `json:"type"`
+	Value string `json:"value"`
+}
+
+// transientParameters are filled in by the drivers from the window,
+// so they are neither saved nor loaded
+var transientParameters = map[string]bool{
+	"width":        true,
+	"height":       true,
+	"mouseX":       true,
+	"mouseY":       true,
+	"sidebarWidth": true,
+}
+
+// loadOrder sorts zoom first and the viewport last when loading, so a
+// parameter reacting to a change, like a custom zoom, cannot move the
+// restored view
+func loadOrder(name string) int {
+	switch name {
+	case "zoom":
+		return 0
+	case "left", "top", "right", "bottom":
+		return 2
+	}
+	return 1
+}
+
+// parameterState captures the savable parameters of m; the caller holds the model lock
+func parameterState(m RenderModel) []SavedParameter {
+	saved := make([]SavedParameter, 0, 10)
+	for _, name := range m.GetParameterNames() {
+		p := m.GetParameter(name)
+		if transientParameters[name] || IsReadOnly(p) {
+			continue
+		}
+		saved = append(saved, SavedParameter{
+			Name:  name,
+			Type:  p.GetType(),
+			Value: GetParameterValueAsString(p),
+		})
+	}
+	return saved
+}
+
+// applyState sets the parameters of m from saved, returning the first
+// error; the caller holds the model lock
+func applyState(m RenderModel, saved []SavedParameter) error {
+	ordered := make([]SavedParameter, len(saved))
+	copy(ordered, saved)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		return loadOrder(ordered[i].Name) < loadOrder(ordered[j].Name)
+	})
+
+	known := make(map[string]bool)
+	for _, name := range m.GetParameterNames() {
+		known[name] = true
+	}
+	var first error
+	for _, s := range ordered {
+		if !known[s.Name] || transientParameters[s.Name] {
+			continue
+		}
+		p := m.GetParameter(s.Name)
+		if IsReadOnly(p) || GetParameterValueAsString(p) == s.Value {
+			continue
+		}
+		var err error
+		if p.GetType() != s.Type {
+			err = fmt.Errorf("%v: saved as %v, but is now %v", s.Name, s.Type, p.GetType())
+		} else {
+			err = SetParameterValueFromString(p, s.Value)
+		}
+		if err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
+
+// SaveParameters writes the name, type and value of each of the model's
+// parameters to w as JSON, skipping those the drivers fill in and the
+// read-only outputs of the renderer
+func SaveParameters(w io.Writer, m RenderModel) error {
+	m.Lock()
+	saved := parameterState(m)
+	m.Unlock()
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "\t")
+	return enc.Encode(saved)
+}
+
+// LoadParameters reads parameters written by SaveParameters and sets them
+// through SetParameterValueFromString. Parameters the model lacks are
+// ignored. Every value that can be set is set; the first error is returned.
+func LoadParameters(r io.Reader, m RenderModel) error {
+	var saved []SavedParameter
+	if err := json.NewDecoder(r).Decode(&saved); err != nil {
+		return err
+	}
+
+	m.Lock()
+	defer m.Unlock()
+	return applyState(m, saved)
+}
+
+// SaveParametersToFile writes the model's parameters to filename
+func SaveParametersToFile(filename string, m RenderModel) error {
+	f, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	err = SaveParameters(f, m)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// LoadParametersFromFile sets the model's parameters from filename
+func LoadParametersFromFile(filename string, m RenderModel) error {
+	f, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return LoadParameters(f, m)
+}
+
+// DefaultParameterFile is used by drivers without a file chooser:
+// the program name with a .rv.json extension, in the working directory
+func DefaultParameterFile() string {
+	name := strings.TrimSuffix(filepath.Base(os.Args[0]), filepath.Ext(os.Args[0]))
+	return name + ".rv.json"
+}
+
+// SessionFile returns where the session of this program is kept, under
+// the user's configuration directory
+func SessionFile() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "renderview", DefaultParameterFile()), nil
+}
+
+func restoreSessionEnabled(m RenderModel) bool {
+	return m.GetParameter("options").GetValueInt()&OPT_RESTORE_SESSION == OPT_RESTORE_SESSION
+}
+
+// RestoreSession loads the parameters saved by SaveSession, if the model's
+// options include OPT_RESTORE_SESSION. A missing session is not an error.
+// Drivers call this once they have set the paint function, as restoring
+// parameters may request a paint.
+func RestoreSession(m RenderModel) error {
+	if !restoreSessionEnabled(m) {
+		return nil
+	}
+	filename, err := SessionFile()
+	if err != nil {
+		return err
+	}
+	err = LoadParametersFromFile(filename, m)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+// SaveSession saves the parameters for RestoreSession, if the model's
+// options include OPT_RESTORE_SESSION. Drivers call this when the window closes.
+func SaveSession(m RenderModel) error {
+	if !restoreSessionEnabled(m) {
+		return nil
+	}
+	filename, err := SessionFile()
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+		return err
+	}
+	return SaveParametersToFile(filename, m)
+}
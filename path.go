@@ -0,0 +1,124 @@
+// Copyright 2020 Howard C. Shaw III. All rights reserved.
+// Use of this source code is governed by the MIT-license
+// as defined in the LICENSE file.
+
+package renderview
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// PathMode says what a path parameter names, and so which kind of
+// chooser the drivers offer for it
+type PathMode int
+
+const (
+	PATH_OPEN      PathMode = iota // an existing file to read
+	PATH_SAVE                      // a file to write, which need not exist yet
+	PATH_DIRECTORY                 // a directory
+)
+
+// PathRenderParameter is a string parameter holding a file or directory
+// path. The drivers show it as an entry with a way to browse for the path,
+// offering only files matching Patterns, if any are given.
+type PathRenderParameter struct {
+	EmptyParameter
+
+	Value    string
+	Mode     PathMode
+	Patterns []string
+}
+
+func (e *PathRenderParameter) GetValueString() string {
+	return e.Value
+}
+
+func (e *PathRenderParameter) SetValueString(v string) string {
+	old := e.Value
+	e.Value = v
+	e.NotifyChange(old, e.Value)
+	return e.Value
+}
+
+// GetPathOptions returns the mode and glob patterns of a path parameter
+func (e *PathRenderParameter) GetPathOptions() (mode PathMode, patterns []string) {
+	return e.Mode, e.Patterns
+}
+
+// NewPathRP creates a path parameter. Patterns are globs, like *.gif,
+// restricting the files the drivers' choosers offer.
+func NewPathRP(name string, value string, mode PathMode, patterns ...string) *PathRenderParameter {
+	return &PathRenderParameter{
+		EmptyParameter: EmptyParameter{
+			Name: name,
+			Type: "path",
+		},
+		Value:    value,
+		Mode:     mode,
+		Patterns: patterns,
+	}
+}
+
+// ParsePathMode reads the names open, save and dir
+func ParsePathMode(s string) (PathMode, error) {
+	switch s {
+	case "", "open":
+		return PATH_OPEN, nil
+	case "save":
+		return PATH_SAVE, nil
+	case "dir":
+		return PATH_DIRECTORY, nil
+	}
+	return PATH_OPEN, fmt.Errorf("unknown path mode %q, expected open, save or dir", s)
+}
+
+// MatchPathPatterns reports whether the file name matches one of the
+// patterns; every name matches an empty list
+func MatchPathPatterns(name string, patterns []string) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+	base := filepath.Base(name)
+	for _, pattern := range patterns {
+		if ok, _ := filepath.Match(pattern, base); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// CompletePath lists the paths that could complete prefix: the entries
+// of its directory starting with its last element, with a trailing
+// separator on directories. In PATH_DIRECTORY mode only directories are
+// listed, and otherwise only directories and files matching patterns.
+func CompletePath(prefix string, mode PathMode, patterns []string) []string {
+	dir, base := filepath.Split(prefix)
+	list := dir
+	if list == "" {
+		list = "."
+	}
+	entries, err := ioutil.ReadDir(list)
+	if err != nil {
+		return nil
+	}
+	var r []string
+	for _, e := range entries {
+		name := e.Name()
+		if !strings.HasPrefix(name, base) || strings.HasPrefix(name, ".") && !strings.HasPrefix(base, ".") {
+			continue
+		}
+		switch {
+		case e.IsDir():
+			r = append(r, dir+name+string(os.PathSeparator))
+		case mode != PATH_DIRECTORY && MatchPathPatterns(name, patterns):
+			r = append(r, dir+name)
+		}
+	}
+	sort.Strings(r)
+	return r
+}
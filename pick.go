@@ -0,0 +1,108 @@
+// Copyright 2020 Howard C. Shaw III. All rights reserved.
+// Use of this source code is governed by the MIT-license
+// as defined in the LICENSE file.
+
+package renderview
+
+import (
+	"fmt"
+	"image"
+	"image/draw"
+)
+
+// CROSSHAIR_SIZE is the length in pixels of each arm of the crosshair
+// marking a picked point
+const CROSSHAIR_SIZE = 8
+
+// SetPickPoint sets a complex128 parameter to x+yi, or a point parameter
+// to x, y, subject to the parameter's validator
+func SetPickPoint(p RenderParameter, x, y float64) error {
+	var value interface{}
+	switch p.GetType() {
+	case "complex128":
+		value = complex(x, y)
+	case "point":
+		value = Point{x, y}
+	default:
+		return fmt.Errorf("%v: cannot pick a %v parameter", p.GetName(), p.GetType())
+	}
+	if err := p.Validate(value); err != nil {
+		return err
+	}
+	return SetParameterValue(p, value)
+}
+
+// GetPickPoint returns the position held by a complex128 or point
+// parameter, and false for any other
+func GetPickPoint(p RenderParameter) (x, y float64, ok bool) {
+	switch v := GetParameterValue(p).(type) {
+	case complex128:
+		return real(v), imag(v), true
+	case Point:
+		return v.X, v.Y, true
+	}
+	return 0, 0, false
+}
+
+// PickAt sets the first parameter of m hinted HINT_PICK to the model
+// coordinates of the pixel x, y, recording the change in the history, and
+// reports whether there was one to set. It locks the model itself.
+func PickAt(m RenderModel, x, y float64) bool {
+	m.Lock()
+	names := m.GetHintedParameterNames(HINT_PICK)
+	m.Unlock()
+	if len(names) == 0 {
+		return false
+	}
+	m.GetHistory().Checkpoint(names[0])
+	m.Lock()
+	defer m.Unlock()
+	wx, wy := NewViewport(m).ToWorld(x, y)
+	return SetPickPoint(m.GetParameter(names[0]), wx, wy) == nil
+}
+
+// PickMarkers returns the pixel positions of the points held by the
+// parameters of m hinted HINT_PICK. Callers hold the model lock.
+func PickMarkers(m RenderModel) []image.Point {
+	view := NewViewport(m)
+	if view.Width.Get() <= 0 || view.Height.Get() <= 0 ||
+		view.Right.Get() == view.Left.Get() || view.Bottom.Get() == view.Top.Get() {
+		return nil
+	}
+	var pts []image.Point
+	for _, name := range m.GetHintedParameterNames(HINT_PICK) {
+		if x, y, ok := GetPickPoint(m.GetParameter(name)); ok {
+			px, py := view.ToPixel(x, y)
+			pts = append(pts, image.Pt(int(px), int(py)))
+		}
+	}
+	return pts
+}
+
+// CrosshairRects returns the rectangles making up the crosshair marking
+// pt: black outlines, to be filled first, and white arms to fill over
+// them, so the marker shows against any image. The centre is left open.
+func CrosshairRects(pt image.Point) (outlines []image.Rectangle, arms []image.Rectangle) {
+	const gap = 2
+	arms = []image.Rectangle{
+		image.Rect(pt.X-gap-CROSSHAIR_SIZE, pt.Y, pt.X-gap, pt.Y+1),
+		image.Rect(pt.X+gap+1, pt.Y, pt.X+gap+1+CROSSHAIR_SIZE, pt.Y+1),
+		image.Rect(pt.X, pt.Y-gap-CROSSHAIR_SIZE, pt.X+1, pt.Y-gap),
+		image.Rect(pt.X, pt.Y+gap+1, pt.X+1, pt.Y+gap+1+CROSSHAIR_SIZE),
+	}
+	for _, a := range arms {
+		outlines = append(outlines, a.Inset(-1))
+	}
+	return outlines, arms
+}
+
+// DrawCrosshair draws the crosshair marking pt onto dst
+func DrawCrosshair(dst draw.Image, pt image.Point) {
+	outlines, arms := CrosshairRects(pt)
+	for _, r := range outlines {
+		draw.Draw(dst, r, image.Black, image.ZP, draw.Src)
+	}
+	for _, r := range arms {
+		draw.Draw(dst, r, image.White, image.ZP, draw.Src)
+	}
+}
@@ -0,0 +1,84 @@
+// Copyright 2020 Howard C. Shaw III. All rights reserved.
+// Use of this source code is governed by the MIT-license
+// as defined in the LICENSE file.
+
+package renderview
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Point is the value of a point parameter, a position in the model's
+// own coordinates (those of left, top, right and bottom)
+type Point struct {
+	X, Y float64
+}
+
+// PointRenderParameter holds a 2-D point, with the type "point". Its
+// string form is the coordinates separated by a comma (see FormatPoint).
+type PointRenderParameter struct {
+	EmptyParameter
+
+	Value Point
+}
+
+// GetValuePoint returns the point
+func (e *PointRenderParameter) GetValuePoint() Point {
+	return e.Value
+}
+
+// SetValuePoint stores v and returns it
+func (e *PointRenderParameter) SetValuePoint(v Point) Point {
+	old := e.Value
+	e.Value = v
+	e.NotifyChange(old, e.Value)
+	return e.Value
+}
+
+func (e *PointRenderParameter) GetValueString() string {
+	return FormatPoint(e.Value)
+}
+
+// SetValueString parses v as a point, leaving the point as it was if v
+// does not parse, and returns the point's string form
+func (e *PointRenderParameter) SetValueString(v string) string {
+	if pt, err := ParsePoint(v); err == nil {
+		e.SetValuePoint(pt)
+	}
+	return e.GetValueString()
+}
+
+func NewPointRP(name string, x float64, y float64) *PointRenderParameter {
+	return &PointRenderParameter{
+		EmptyParameter: EmptyParameter{
+			Name: name,
+			Type: "point",
+		},
+		Value: Point{x, y},
+	}
+}
+
+// FormatPoint writes a point as x, y
+func FormatPoint(pt Point) string {
+	return strconv.FormatFloat(pt.X, 'g', -1, 64) + ", " + strconv.FormatFloat(pt.Y, 'g', -1, 64)
+}
+
+// ParsePoint reads a point written as x, y; each coordinate may be an
+// expression, as for float64 parameters
+func ParsePoint(v string) (Point, error) {
+	l := strings.Split(v, ",")
+	if len(l) != 2 {
+		return Point{}, fmt.Errorf("expected x, y")
+	}
+	x, err := ParseFloatExpression(strings.TrimSpace(l[0]))
+	if err != nil {
+		return Point{}, err
+	}
+	y, err := ParseFloatExpression(strings.TrimSpace(l[1]))
+	if err != nil {
+		return Point{}, err
+	}
+	return Point{x, y}, nil
+}
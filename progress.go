@@ -0,0 +1,100 @@
+// Copyright 2020 Howard C. Shaw III. All rights reserved.
+// Use of this source code is governed by the MIT-license
+// as defined in the LICENSE file.
+
+package renderview
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+	"time"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+)
+
+// Progress is how far a render has got
+type Progress struct {
+	// Active is set while a render runs
+	Active bool
+	// Fraction is the share of the render done, from 0 to 1, as last
+	// reported by the renderer
+	Fraction float64
+	// Started is when the render began
+	Started time.Time
+}
+
+// Reported tells whether a render is running and has reported progress,
+// which is when the drivers show it
+func (p Progress) Reported() bool {
+	return p.Active && p.Fraction > 0
+}
+
+// Remaining estimates the time the render has left from its pace so
+// far, and false if there is no pace to go by yet
+func (p Progress) Remaining() (time.Duration, bool) {
+	if !p.Reported() {
+		return 0, false
+	}
+	elapsed := time.Since(p.Started)
+	return time.Duration(float64(elapsed) * (1 - p.Fraction) / p.Fraction), true
+}
+
+// String describes the progress, like 42%, about 3s left
+func (p Progress) String() string {
+	if !p.Active {
+		return ""
+	}
+	s := fmt.Sprintf("%.0f%%", math.Floor(p.Fraction*100))
+	if left, ok := p.Remaining(); ok && left >= time.Second {
+		s += fmt.Sprintf(", about %v left", left.Round(time.Second))
+	}
+	return s
+}
+
+// ProgressModel is implemented by models whose renders report their
+// progress, as BasicRenderModel does
+type ProgressModel interface {
+	GetProgress() Progress
+}
+
+// GetProgress returns the progress of the render m is running, or no
+// progress if m does not report it. It locks the model itself.
+func GetProgress(m RenderModel) Progress {
+	if pm, ok := m.(ProgressModel); ok {
+		return pm.GetProgress()
+	}
+	return Progress{}
+}
+
+// PROGRESS_BAR_HEIGHT is the height in pixels of the bar DrawProgress
+// overlays on the image
+const PROGRESS_BAR_HEIGHT = 16
+
+// DrawProgress overlays a bar showing p, labeled with its String, across
+// the bottom of area of dst. It draws nothing unless p is Reported.
+func DrawProgress(dst draw.Image, area image.Rectangle, p Progress) {
+	if !p.Reported() {
+		return
+	}
+	bar := image.Rect(area.Min.X, area.Max.Y-PROGRESS_BAR_HEIGHT, area.Max.X, area.Max.Y).Intersect(area)
+	if bar.Empty() {
+		return
+	}
+	draw.Draw(dst, bar, image.NewUniform(color.RGBA{0x20, 0x20, 0x20, 0xc0}), image.ZP, draw.Over)
+	done := bar
+	done.Max.X = bar.Min.X + int(float64(bar.Dx())*math.Min(p.Fraction, 1))
+	draw.Draw(dst, done, image.NewUniform(color.RGBA{0x40, 0x80, 0xe0, 0xe0}), image.ZP, draw.Over)
+	face := basicfont.Face7x13
+	d := font.Drawer{
+		Dst:  dst,
+		Src:  image.White,
+		Face: face,
+		Dot:  fixed.P(bar.Min.X+4, bar.Max.Y-(bar.Dy()-face.Metrics().Ascent.Ceil())/2-1),
+	}
+	d.DrawString(p.String())
+}
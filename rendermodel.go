@@ -5,9 +5,11 @@
 package renderview
 
 import (
+	"context"
 	"image"
 	"math"
 	"sync"
+	"time"
 )
 
 // RenderModel is the interface you will implement to stand between your visualization code
@@ -20,10 +22,14 @@ type RenderModel interface {
 	GetParameterNames() []string
 	GetHintedParameterNames(hint int) []string
 	GetHintedParameterNamesWithFallback(hint int) []string
+	GetParameterGroups(hint int) []ParameterGroup
 	GetParameter(name string) RenderParameter
 	Render() image.Image
 	SetRequestPaintFunc(func())
 	GetRequestPaintFunc() func()
+	GetHistory() *History
+	GetBookmarks() *Bookmarks
+	GetTimeline() *Timeline
 }
 
 // EmptyRenderModel concretizes the most important elements of the RenderModel, the bag of Parameters (Params)
@@ -35,6 +41,15 @@ type EmptyRenderModel struct {
 
 	Params       []RenderParameter
 	RequestPaint func()
+
+	// GroupOrder lists the names of parameter groups in the order the
+	// sidebar shows them; unlisted groups follow in order of appearance
+	GroupOrder []string
+
+	history     *History
+	bookmarks   *Bookmarks
+	timeline    *Timeline
+	derivations *Derivations
 }
 
 // GetParameterNames returns a list of valid parameter names
@@ -57,18 +72,25 @@ func (e *EmptyRenderModel) GetHintedParameterNames(hints int) []string {
 	return s
 }
 
-// GetHintedParameterNamesWithFallback retrieves the names of parameters matching hints,
-// if that is the empty set, it retrieves the names of parameters with no hints
+// GetHintedParameterNamesWithFallback retrieves the names of parameters matching hints.
+// If no parameter is placed in the sidebar or footer at all, it retrieves instead the
+// names of parameters with no hints other than HINT_READONLY and HINT_PICK, so a
+// model hinting only its footer does not get every other parameter in its sidebar.
 func (e *EmptyRenderModel) GetHintedParameterNamesWithFallback(hints int) []string {
 	s := make([]string, 0, len(e.Params))
+	placed := false
 	for i := 0; i < len(e.Params); i++ {
 		if e.Params[i].GetHint()&hints > 0 {
 			s = append(s, e.Params[i].GetName())
 		}
+		if e.Params[i].GetHint()&(HINT_SIDEBAR|HINT_FOOTER) > 0 {
+			placed = true
+		}
 	}
-	if len(s) == 0 {
+	if !placed {
+		s = s[:0]
 		for i := 0; i < len(e.Params); i++ {
-			if e.Params[i].GetHint() == 0 {
+			if e.Params[i].GetHint()&^(HINT_READONLY|HINT_PICK) == 0 {
 				s = append(s, e.Params[i].GetName())
 			}
 		}
@@ -76,6 +98,17 @@ func (e *EmptyRenderModel) GetHintedParameterNamesWithFallback(hints int) []stri
 	return s
 }
 
+// GetParameterGroups sorts the parameters GetHintedParameterNamesWithFallback
+// returns for hints into groups, ordered as described by GroupParameters
+func (e *EmptyRenderModel) GetParameterGroups(hints int) []ParameterGroup {
+	return GroupParameters(e, e.GetHintedParameterNamesWithFallback(hints), e.GroupOrder)
+}
+
+// SetGroupOrder sets the order in which the sidebar shows the named groups
+func (e *EmptyRenderModel) SetGroupOrder(groups ...string) {
+	e.GroupOrder = groups
+}
+
 // GetParameter returns a named parameter. If you implement your own RenderModel from scratch,
 // without using the EmptyRenderModel as a basis, you must either include ALL the default
 // parameters, or duplicate the behavior of EmptyRenderModel in returning an EmptyParameter
@@ -100,6 +133,61 @@ func (e *EmptyRenderModel) AddParameters(Params ...RenderParameter) {
 	e.Params = append(e.Params, Params...)
 }
 
+// BindStruct adds a parameter for each exported field of the struct v
+// points to and keeps the fields synchronized with them. See the package
+// level BindStruct for the struct tags understood.
+func (e *EmptyRenderModel) BindStruct(v interface{}) error {
+	params, err := BindStruct(v)
+	if err != nil {
+		return err
+	}
+	e.AddParameters(params...)
+	return nil
+}
+
+// GetHistory returns the model's undo history, creating it on first use
+func (e *EmptyRenderModel) GetHistory() *History {
+	if e.history == nil {
+		e.history = NewHistory(e)
+	}
+	return e.history
+}
+
+// GetBookmarks returns the model's bookmarks, creating the list on first
+// use; call Load on it to read the bookmarks saved by an earlier run
+func (e *EmptyRenderModel) GetBookmarks() *Bookmarks {
+	if e.bookmarks == nil {
+		e.bookmarks = NewBookmarks(e)
+	}
+	return e.bookmarks
+}
+
+// GetTimeline returns the model's animation timeline, creating it on
+// first use. The timeline renders frames for export through the model it
+// was created with, so a model implementing Render itself should
+// override GetTimeline to create it with NewTimeline(m).
+func (e *EmptyRenderModel) GetTimeline() *Timeline {
+	if e.timeline == nil {
+		e.timeline = NewTimeline(e)
+	}
+	return e.timeline
+}
+
+// Derive makes the target parameter follow f applied to the named
+// inputs, recomputing it whenever one of them changes; see Derivations
+func (e *EmptyRenderModel) Derive(target string, inputs []string, f DeriveFunc) error {
+	if e.derivations == nil {
+		e.derivations = NewDerivations(e)
+	}
+	return e.derivations.Add(target, inputs, f)
+}
+
+// Snapshot locks the model and copies the values of all its parameters;
+// see TakeSnapshot
+func (e *EmptyRenderModel) Snapshot() Snapshot {
+	return TakeSnapshot(e)
+}
+
 // Included for completeness. In general, there is no need for your code to use
 // the RenderModel interface instead of a concrete form, so you can simply
 // access e.RequestPaint directly.
@@ -139,8 +227,40 @@ type BasicRenderModel struct {
 	Img           image.Image
 
 	started bool
+	// rendering serializes calls of InnerRender from GoRender and RenderSync
+	rendering sync.Mutex
+	// watched holds the parameters whose changes cancel a render
+	watched map[RenderParameter]bool
+	// cancelLock guards cancel and ctx, which parameters call back into
+	// while the model lock is held
+	cancelLock sync.Mutex
+	cancel     context.CancelFunc
+	ctx        context.Context
+	progress   Progress
+	// partial is set by Publish and SetProgress until the view calls
+	// Render to fetch what they published
+	partial bool
+
+	// InnerRender is handed a Snapshot of the parameters taken just before
+	// it is called, so it can read them without holding the lock.
+	InnerRender func(s Snapshot)
 
-	InnerRender func()
+	// InnerRenderContext, if set, is called instead of InnerRender and
+	// returns the image rather than storing it. Its context is canceled as
+	// soon as a parameter changes or Rerender is called; return
+	// early when it is, and the model discards whatever you return and
+	// renders again. The model sets Rendering, stores the image in Img and
+	// calls RequestPaint itself. Set only outputs from it, as changing any
+	// other parameter cancels the render.
+	InnerRenderContext func(ctx context.Context, s Snapshot) image.Image
+}
+
+// unwatchedParameters change without affecting the image, so they do not
+// cancel a render
+var unwatchedParameters = map[string]bool{
+	"mouseX":       true,
+	"mouseY":       true,
+	"sidebarWidth": true,
 }
 
 // Called by RenderView
@@ -149,30 +269,227 @@ func (m *BasicRenderModel) Render() image.Image {
 	defer m.Unlock()
 	rendering := m.Rendering
 	if !rendering {
-		m.RequestRender <- true
+		// a request already queued covers this one, and blocking here
+		// while holding the lock would stall GoRender taking its Snapshot
+		select {
+		case m.RequestRender <- true:
+		default:
+		}
 		m.NeedsRender = false
+	} else if m.partial {
+		// the view is fetching a published image, not asking for a new render
+		m.partial = false
 	} else {
 		m.NeedsRender = true
 	}
 	return m.Img
 }
 
+// Rerender asks for a new render, canceling the one in progress. Changes
+// to parameters do this themselves; call it when the image depends on
+// something else that changed.
+func (m *BasicRenderModel) Rerender() {
+	m.Lock()
+	if m.Rendering {
+		m.NeedsRender = true
+	} else {
+		select {
+		case m.RequestRender <- true:
+		default:
+		}
+	}
+	m.Unlock()
+	m.cancelRender()
+}
+
 // GoRender is called by Start and calls your provided InnerRender function when needed.
 func (m *BasicRenderModel) GoRender() {
 	for {
 		select {
 		case <-m.RequestRender:
-			if !(m.InnerRender == nil) {
-				m.InnerRender()
-				if m.NeedsRender {
-					m.NeedsRender = false
-					m.InnerRender()
+			if m.InnerRenderContext != nil {
+				for m.renderContext() {
 				}
+			} else if !(m.InnerRender == nil) {
+				m.rendering.Lock()
+				m.startProgress()
+				m.InnerRender(m.Snapshot())
+				if m.needsRender() {
+					m.startProgress()
+					m.InnerRender(m.Snapshot())
+				}
+				m.stopProgress()
+				m.rendering.Unlock()
 			}
 		}
 	}
 }
 
+// GetTimeline returns the model's animation timeline, creating it on
+// first use, so that exporting it renders through RenderSync
+func (m *BasicRenderModel) GetTimeline() *Timeline {
+	if m.timeline == nil {
+		m.timeline = NewTimeline(m)
+	}
+	return m.timeline
+}
+
+// RenderSync calls InnerRender with the current parameters and waits
+// for it, returning the image it left in Img. It is used to render
+// headlessly, where there is no view to repaint when a render finishes.
+func (m *BasicRenderModel) RenderSync() image.Image {
+	if m.InnerRender != nil {
+		m.rendering.Lock()
+		m.InnerRender(m.Snapshot())
+		m.rendering.Unlock()
+	}
+	if m.InnerRenderContext != nil {
+		m.rendering.Lock()
+		img := m.InnerRenderContext(context.Background(), m.Snapshot())
+		m.rendering.Unlock()
+		m.Lock()
+		m.Img = img
+		m.partial = false
+		m.Unlock()
+		return img
+	}
+	m.Lock()
+	defer m.Unlock()
+	m.partial = false
+	return m.Img
+}
+
+// renderContext runs InnerRenderContext once, keeping the image unless
+// the render was canceled, and reports whether another render is needed
+func (m *BasicRenderModel) renderContext() bool {
+	m.rendering.Lock()
+	defer m.rendering.Unlock()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	m.Lock()
+	m.watchParameters()
+	m.Rendering = true
+	m.NeedsRender = false
+	m.Unlock()
+	m.cancelLock.Lock()
+	m.cancel, m.ctx = cancel, ctx
+	m.cancelLock.Unlock()
+	m.startProgress()
+
+	img := m.InnerRenderContext(ctx, m.Snapshot())
+
+	m.cancelLock.Lock()
+	m.cancel, m.ctx = nil, nil
+	m.cancelLock.Unlock()
+	m.stopProgress()
+	m.Lock()
+	canceled := ctx.Err() != nil
+	if !canceled {
+		m.Img = img
+	}
+	m.Rendering = false
+	again := canceled || m.NeedsRender
+	m.NeedsRender = false
+	paint := m.RequestPaint
+	m.Unlock()
+	if !canceled && paint != nil {
+		paint()
+	}
+	return again
+}
+
+// Publish shows img, an unfinished image, in the view and reports the
+// render as fraction done, from 0 to 1. Call it from InnerRender or
+// InnerRenderContext as the render goes on; it is ignored once the
+// render is canceled. The view may read img at any time, so publish a
+// copy if you go on drawing into it.
+func (m *BasicRenderModel) Publish(img image.Image, fraction float64) {
+	m.report(img, fraction)
+}
+
+// SetProgress reports the render as fraction done, from 0 to 1, without
+// changing the image
+func (m *BasicRenderModel) SetProgress(fraction float64) {
+	m.report(nil, fraction)
+}
+
+func (m *BasicRenderModel) report(img image.Image, fraction float64) {
+	m.cancelLock.Lock()
+	canceled := m.ctx != nil && m.ctx.Err() != nil
+	m.cancelLock.Unlock()
+	if canceled {
+		return
+	}
+	m.Lock()
+	if img != nil {
+		m.Img = img
+	}
+	m.progress.Fraction = math.Max(0, math.Min(fraction, 1))
+	paint := m.RequestPaint
+	m.partial = paint != nil
+	m.Unlock()
+	if paint != nil {
+		paint()
+	}
+}
+
+// GetProgress returns the progress of the render in progress; it locks
+// the model itself
+func (m *BasicRenderModel) GetProgress() Progress {
+	m.Lock()
+	defer m.Unlock()
+	return m.progress
+}
+
+func (m *BasicRenderModel) startProgress() {
+	m.Lock()
+	defer m.Unlock()
+	m.progress = Progress{Active: true, Started: time.Now()}
+}
+
+func (m *BasicRenderModel) stopProgress() {
+	m.Lock()
+	defer m.Unlock()
+	m.progress.Active = false
+	m.partial = false
+}
+
+// watchParameters subscribes to the parameters added since the last
+// render, so that changing one cancels the render in progress. Outputs
+// are left out, as a render sets them itself. The caller holds the lock.
+func (m *BasicRenderModel) watchParameters() {
+	if m.watched == nil {
+		m.watched = make(map[RenderParameter]bool)
+	}
+	for _, p := range m.Params {
+		if m.watched[p] || IsReadOnly(p) || unwatchedParameters[p.GetName()] {
+			continue
+		}
+		m.watched[p] = true
+		p.OnChange(func(oldValue interface{}, newValue interface{}) {
+			m.cancelRender()
+		})
+	}
+}
+
+// cancelRender cancels the context of the render in progress, if any
+func (m *BasicRenderModel) cancelRender() {
+	m.cancelLock.Lock()
+	defer m.cancelLock.Unlock()
+	if m.cancel != nil {
+		m.cancel()
+	}
+}
+
+// needsRender reports and clears a render request that arrived while rendering
+func (m *BasicRenderModel) needsRender() bool {
+	m.Lock()
+	defer m.Unlock()
+	needs := m.NeedsRender
+	m.NeedsRender = false
+	return needs
+}
+
 // Start only needs to be called if you have embedded BasicRenderModel in your own struct.
 func (m *BasicRenderModel) Start() {
 	if !m.started {
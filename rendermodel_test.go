@@ -0,0 +1,121 @@
+// Copyright 2020 Howard C. Shaw III. All rights reserved.
+// Use of this source code is governed by the MIT-license
+// as defined in the LICENSE file.
+
+package renderview
+
+import (
+	"context"
+	"image"
+	"math"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+// TestPanZoomDuringRender pans and zooms the viewport the way the drivers
+// do while the view loops on Render and the model renders in the
+// background. Run it with -race. Pans and zooms keep the aspect of the
+// bounds, so a render seeing any other aspect saw them half updated.
+func TestPanZoomDuringRender(t *testing.T) {
+	for _, withContext := range []bool{false, true} {
+		m := NewBasicRenderModel()
+		m.AddParameters(DefaultParameters(false, HINT_SIDEBAR, OPT_AUTO_ZOOM, -2, -1, 1, 1)...)
+
+		var torn, renders int32
+		render := func(s Snapshot) image.Image {
+			atomic.AddInt32(&renders, 1)
+			aspect := (s.Float64("right") - s.Float64("left")) / (s.Float64("bottom") - s.Float64("top"))
+			if math.Abs(aspect-1.5) > 1e-6 {
+				atomic.AddInt32(&torn, 1)
+			}
+			return image.NewRGBA(image.Rect(0, 0, s.Int("width"), s.Int("height")))
+		}
+		if withContext {
+			m.InnerRenderContext = func(ctx context.Context, s Snapshot) image.Image {
+				return render(s)
+			}
+		} else {
+			m.InnerRender = func(s Snapshot) {
+				img := render(s)
+				m.Lock()
+				m.Img = img
+				m.Unlock()
+			}
+		}
+
+		// a widget watching left, as the drivers' parameter widgets do
+		dirty := false
+		m.GetParameter("left").OnChange(func(oldValue, newValue interface{}) {
+			dirty = true
+		})
+
+		done := make(chan struct{})
+		var wg sync.WaitGroup
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+				m.Render()
+				m.Lock()
+				if dirty {
+					dirty = false
+				}
+				m.Unlock()
+			}
+		}()
+
+		view := NewViewport(m)
+		// keep panning until enough renders have overlapped with it
+		for i := 0; i < 500 || atomic.LoadInt32(&renders) < 50 && i < 1000000; i++ {
+			m.Lock()
+			if i%100 == 0 {
+				// zooming in and out shrinks the bounds a little, so
+				// go back to the start before they lose precision
+				view.Left.Set(-2)
+				view.Top.Set(-1)
+				view.Right.Set(1)
+				view.Bottom.Set(1)
+			}
+			switch i % 4 {
+			case 0:
+				view.Pan(3, -2)
+			case 1:
+				view.ZoomAt(20, 30, false)
+			case 2:
+				view.Pan(-5, 1)
+			case 3:
+				view.ZoomAt(70, 10, true)
+			}
+			m.Unlock()
+		}
+		close(done)
+		wg.Wait()
+		m.RenderSync()
+
+		if n := atomic.LoadInt32(&torn); n > 0 {
+			t.Errorf("context %v: %d renders saw torn bounds", withContext, n)
+		}
+		if atomic.LoadInt32(&renders) == 0 {
+			t.Errorf("context %v: nothing rendered", withContext)
+		}
+	}
+}
+
+func TestSidebarFallback(t *testing.T) {
+	m := NewBasicRenderModel()
+	m.AddParameters(NewIntRP("a", 0), NewIntRP("b", 0))
+	m.AddParameters(SetHints(HINT_HIDE, NewIntRP("hidden", 0))...)
+	if got := m.GetHintedParameterNamesWithFallback(HINT_SIDEBAR); len(got) != 2 {
+		t.Errorf("unplaced parameters gave sidebar %v, want [a b]", got)
+	}
+	m.AddParameters(SetHints(HINT_FOOTER, NewIntRP("f", 0))...)
+	if got := m.GetHintedParameterNamesWithFallback(HINT_SIDEBAR); len(got) != 0 {
+		t.Errorf("with a footer the sidebar is %v, want it empty", got)
+	}
+}
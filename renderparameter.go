@@ -7,6 +7,8 @@ package renderview
 import (
 	"bytes"
 	"fmt"
+	"image/color"
+	"math"
 	"strconv"
 	"strings"
 )
@@ -17,6 +19,21 @@ const (
 	HINT_SIDEBAR  = 1 << iota
 	HINT_FOOTER   = 1 << iota
 	HINT_FULLTEXT = 1 << iota
+	// HINT_READONLY shows the parameter as a label, for values computed
+	// by the renderer; add HINT_SIDEBAR or HINT_FOOTER to place it
+	HINT_READONLY = 1 << iota
+	// HINT_PICK lets a complex128 or point parameter be set by clicking
+	// in the image, where it is marked with a crosshair (see PickAt)
+	HINT_PICK = 1 << iota
+)
+
+// Metadata keys understood by the drivers; GetMeta and SetMeta accept
+// any other key as well
+const (
+	META_LABEL       = "label"       // name shown in place of the parameter's name
+	META_DESCRIPTION = "description" // sentence shown as a tooltip
+	META_UNIT        = "unit"        // suffix shown after the value
+	META_FORMAT      = "format"      // fmt verb for showing a numeric value, like %.3f
 )
 
 type RenderParameter interface {
@@ -24,22 +41,69 @@ type RenderParameter interface {
 	GetType() string
 	GetHint() int
 	SetHint(int)
+	GetDescription() string
+	SetDescription(string)
+	GetMeta(key string) string
+	SetMeta(key string, value string)
+	GetGroup() string
+	SetGroup(string)
+	GetOrder() int
+	SetOrder(int)
 	GetValueInt() int
 	GetValueUInt32() uint32
 	GetValueFloat64() float64
 	GetValueComplex128() complex128
 	GetValueString() string
+	GetValueBool() bool
+	GetValueColor() color.RGBA
 	SetValueInt(value int) int
 	SetValueUInt32(value uint32) uint32
 	SetValueFloat64(value float64) float64
 	SetValueComplex128(value complex128) complex128
 	SetValueString(value string) string
+	SetValueBool(value bool) bool
+	SetValueColor(value color.RGBA) color.RGBA
+	GetChoices() []string
+	GetRange() (min float64, max float64, step float64, bounded bool)
+	GetPathOptions() (mode PathMode, patterns []string)
+	OnChange(f ChangeFunc)
+	SetValidator(f ValidateFunc)
+	Validate(v interface{}) error
 }
 
+// ChangeFunc receives the previous and new value of a parameter, in
+// the parameter's native type, each time the value changes
+type ChangeFunc func(oldValue interface{}, newValue interface{})
+
 type EmptyParameter struct {
-	Name string
-	Type string
-	Hint int
+	Name        string
+	Type        string
+	Hint        int
+	Description string
+	Group       string
+	Order       int
+	Meta        map[string]string
+
+	listeners []ChangeFunc
+	validator ValidateFunc
+}
+
+// OnChange subscribes f to changes of the parameter's value, whether
+// made by a driver, the model, or another parameter. Subscribe before
+// the parameter is in use; f is called on whichever goroutine made the change.
+func (e *EmptyParameter) OnChange(f ChangeFunc) {
+	e.listeners = append(e.listeners, f)
+}
+
+// NotifyChange calls the subscribed ChangeFuncs if the value differs.
+// Custom parameters should call it from their setters.
+func (e *EmptyParameter) NotifyChange(oldValue interface{}, newValue interface{}) {
+	if sameValue(oldValue, newValue) {
+		return
+	}
+	for _, f := range e.listeners {
+		f(oldValue, newValue)
+	}
 }
 
 func (e *EmptyParameter) GetName() string {
@@ -72,9 +136,67 @@ func (e *EmptyParameter) GetValueComplex128() complex128 {
 	return 0
 }
 
+func (e *EmptyParameter) GetValueBool() bool {
+	return false
+}
+
+func (e *EmptyParameter) GetValueColor() color.RGBA {
+	return color.RGBA{}
+}
+
 func (e *EmptyParameter) SetHint(value int) {
 	e.Hint = value
 }
+
+// GetDescription returns a sentence explaining the parameter to the user,
+// shown by the drivers as a tooltip
+func (e *EmptyParameter) GetDescription() string {
+	return e.Description
+}
+
+func (e *EmptyParameter) SetDescription(value string) {
+	e.Description = value
+}
+
+// GetMeta returns the metadata stored under key, or "" if there is none.
+// META_DESCRIPTION is the same as GetDescription.
+func (e *EmptyParameter) GetMeta(key string) string {
+	if key == META_DESCRIPTION {
+		return e.Description
+	}
+	return e.Meta[key]
+}
+
+func (e *EmptyParameter) SetMeta(key string, value string) {
+	if key == META_DESCRIPTION {
+		e.Description = value
+		return
+	}
+	if e.Meta == nil {
+		e.Meta = make(map[string]string)
+	}
+	e.Meta[key] = value
+}
+
+// GetGroup returns the name of the sidebar section holding the parameter,
+// or "" for the default section
+func (e *EmptyParameter) GetGroup() string {
+	return e.Group
+}
+
+func (e *EmptyParameter) SetGroup(value string) {
+	e.Group = value
+}
+
+// GetOrder returns the position of the parameter within its group;
+// parameters with equal order keep the order they were added in
+func (e *EmptyParameter) GetOrder() int {
+	return e.Order
+}
+
+func (e *EmptyParameter) SetOrder(value int) {
+	e.Order = value
+}
 func (e *EmptyParameter) SetValueInt(value int) int {
 	return 0
 }
@@ -90,6 +212,30 @@ func (e *EmptyParameter) SetValueString(value string) string {
 func (e *EmptyParameter) SetValueComplex128(value complex128) complex128 {
 	return 0
 }
+func (e *EmptyParameter) SetValueBool(value bool) bool {
+	return false
+}
+func (e *EmptyParameter) SetValueColor(value color.RGBA) color.RGBA {
+	return color.RGBA{}
+}
+
+// GetChoices returns the allowed values of a parameter, or nil
+// if it is not restricted to a fixed set
+func (e *EmptyParameter) GetChoices() []string {
+	return nil
+}
+
+// GetRange returns the bounds and step of a numeric parameter;
+// bounded is false if the parameter accepts any value
+func (e *EmptyParameter) GetRange() (min float64, max float64, step float64, bounded bool) {
+	return 0, 0, 0, false
+}
+
+// GetPathOptions returns the mode and glob patterns of a path parameter;
+// see PathRenderParameter
+func (e *EmptyParameter) GetPathOptions() (mode PathMode, patterns []string) {
+	return PATH_OPEN, nil
+}
 
 type UInt32RenderParameter struct {
 	EmptyParameter
@@ -102,7 +248,9 @@ func (e *UInt32RenderParameter) GetValueUInt32() uint32 {
 }
 
 func (e *UInt32RenderParameter) SetValueUInt32(v uint32) uint32 {
+	old := e.Value
 	e.Value = v
+	e.NotifyChange(old, e.Value)
 	return e.Value
 }
 
@@ -111,19 +259,24 @@ func (e *UInt32RenderParameter) GetValueString() string {
 }
 
 func (e *UInt32RenderParameter) SetValueString(v string) string {
-	r, err := strconv.ParseInt(v, 10, 32)
-	if err != nil {
+	r, err := strconv.ParseUint(v, 10, 32)
+	if err == nil {
 		e.SetValueUInt32(uint32(r))
-	} else {
-		e.SetValueUInt32(0)
 	}
 	return e.GetValueString()
 }
 
+// IntRenderParameter holds an int. If Bounded is set, values are clamped
+// to Min..Max and, when Step is above 1, rounded to a multiple of Step from Min,
+// so the largest value is the last multiple not above Max.
 type IntRenderParameter struct {
 	EmptyParameter
 
-	Value int
+	Value   int
+	Bounded bool
+	Min     int
+	Max     int
+	Step    int
 }
 
 func (e *IntRenderParameter) GetValueInt() int {
@@ -131,14 +284,47 @@ func (e *IntRenderParameter) GetValueInt() int {
 }
 
 func (e *IntRenderParameter) SetValueInt(v int) int {
+	if e.Bounded {
+		max := e.Max
+		if e.Step > 1 {
+			v = e.Min + int(math.Round(float64(v-e.Min)/float64(e.Step)))*e.Step
+			// the largest value on the step grid, as Max may be off it
+			if e.Max > e.Min {
+				max = e.Min + (e.Max-e.Min)/e.Step*e.Step
+			}
+		}
+		if v < e.Min {
+			v = e.Min
+		}
+		if v > max {
+			v = max
+		}
+	}
+	old := e.Value
 	e.Value = v
+	e.NotifyChange(old, e.Value)
 	return e.Value
 }
 
+func (e *IntRenderParameter) GetRange() (min float64, max float64, step float64, bounded bool) {
+	step = float64(e.Step)
+	if step < 1 {
+		step = 1
+	}
+	return float64(e.Min), float64(e.Max), step, e.Bounded
+}
+
+// Float64RenderParameter holds a float64. If Bounded is set, values are clamped
+// to Min..Max and, when Step is above 0, rounded to a multiple of Step from Min,
+// so the largest value is the last multiple not above Max.
 type Float64RenderParameter struct {
 	EmptyParameter
 
-	Value float64
+	Value   float64
+	Bounded bool
+	Min     float64
+	Max     float64
+	Step    float64
 }
 
 func (e *Float64RenderParameter) GetValueFloat64() float64 {
@@ -146,10 +332,33 @@ func (e *Float64RenderParameter) GetValueFloat64() float64 {
 }
 
 func (e *Float64RenderParameter) SetValueFloat64(v float64) float64 {
+	if e.Bounded {
+		max := e.Max
+		if e.Step > 0 {
+			v = e.Min + math.Round((v-e.Min)/e.Step)*e.Step
+			// the largest value on the step grid, allowing for rounding
+			// in the division, as Max may be off it
+			if e.Max > e.Min {
+				max = e.Min + math.Floor((e.Max-e.Min)/e.Step+1e-9)*e.Step
+			}
+		}
+		if v < e.Min {
+			v = e.Min
+		}
+		if v > max {
+			v = max
+		}
+	}
+	old := e.Value
 	e.Value = v
+	e.NotifyChange(old, e.Value)
 	return e.Value
 }
 
+func (e *Float64RenderParameter) GetRange() (min float64, max float64, step float64, bounded bool) {
+	return e.Min, e.Max, e.Step, e.Bounded
+}
+
 type Complex128RenderParameter struct {
 	EmptyParameter
 
@@ -161,7 +370,9 @@ func (e *Complex128RenderParameter) GetValueComplex128() complex128 {
 }
 
 func (e *Complex128RenderParameter) SetValueComplex128(v complex128) complex128 {
+	old := e.Value
 	e.Value = v
+	e.NotifyChange(old, e.Value)
 	return e.Value
 }
 
@@ -176,10 +387,75 @@ func (e *StringRenderParameter) GetValueString() string {
 }
 
 func (e *StringRenderParameter) SetValueString(v string) string {
+	old := e.Value
+	e.Value = v
+	e.NotifyChange(old, e.Value)
+	return e.Value
+}
+
+type BoolRenderParameter struct {
+	EmptyParameter
+
+	Value bool
+}
+
+func (e *BoolRenderParameter) GetValueBool() bool {
+	return e.Value
+}
+
+func (e *BoolRenderParameter) SetValueBool(v bool) bool {
+	old := e.Value
 	e.Value = v
+	e.NotifyChange(old, e.Value)
+	return e.Value
+}
+
+type ColorRenderParameter struct {
+	EmptyParameter
+
+	Value color.RGBA
+}
+
+func (e *ColorRenderParameter) GetValueColor() color.RGBA {
+	return e.Value
+}
+
+func (e *ColorRenderParameter) SetValueColor(v color.RGBA) color.RGBA {
+	old := e.Value
+	e.Value = v
+	e.NotifyChange(old, e.Value)
+	return e.Value
+}
+
+// ChoiceRenderParameter is a string parameter restricted to one of
+// a fixed list of Choices. Attempts to set any other value are ignored.
+type ChoiceRenderParameter struct {
+	EmptyParameter
+
+	Value   string
+	Choices []string
+}
+
+func (e *ChoiceRenderParameter) GetValueString() string {
 	return e.Value
 }
 
+func (e *ChoiceRenderParameter) SetValueString(v string) string {
+	for _, c := range e.Choices {
+		if c == v {
+			old := e.Value
+			e.Value = v
+			e.NotifyChange(old, e.Value)
+			break
+		}
+	}
+	return e.Value
+}
+
+func (e *ChoiceRenderParameter) GetChoices() []string {
+	return e.Choices
+}
+
 func NewUInt32RP(name string, value uint32) *UInt32RenderParameter {
 	return &UInt32RenderParameter{
 		EmptyParameter: EmptyParameter{
@@ -200,6 +476,22 @@ func NewIntRP(name string, value int) *IntRenderParameter {
 	}
 }
 
+// NewBoundedIntRP creates an int parameter limited to min..max in increments of step
+func NewBoundedIntRP(name string, value int, min int, max int, step int) *IntRenderParameter {
+	p := &IntRenderParameter{
+		EmptyParameter: EmptyParameter{
+			Name: name,
+			Type: "int",
+		},
+		Bounded: true,
+		Min:     min,
+		Max:     max,
+		Step:    step,
+	}
+	p.SetValueInt(value)
+	return p
+}
+
 func NewFloat64RP(name string, value float64) *Float64RenderParameter {
 	return &Float64RenderParameter{
 		EmptyParameter: EmptyParameter{
@@ -210,6 +502,23 @@ func NewFloat64RP(name string, value float64) *Float64RenderParameter {
 	}
 }
 
+// NewBoundedFloat64RP creates a float64 parameter limited to min..max; a step
+// of 0 allows any value in between
+func NewBoundedFloat64RP(name string, value float64, min float64, max float64, step float64) *Float64RenderParameter {
+	p := &Float64RenderParameter{
+		EmptyParameter: EmptyParameter{
+			Name: name,
+			Type: "float64",
+		},
+		Bounded: true,
+		Min:     min,
+		Max:     max,
+		Step:    step,
+	}
+	p.SetValueFloat64(value)
+	return p
+}
+
 func NewComplex128RP(name string, value complex128) *Complex128RenderParameter {
 	return &Complex128RenderParameter{
 		EmptyParameter: EmptyParameter{
@@ -230,6 +539,43 @@ func NewStringRP(name string, value string) *StringRenderParameter {
 	}
 }
 
+func NewBoolRP(name string, value bool) *BoolRenderParameter {
+	return &BoolRenderParameter{
+		EmptyParameter: EmptyParameter{
+			Name: name,
+			Type: "bool",
+		},
+		Value: value,
+	}
+}
+
+func NewColorRP(name string, value color.RGBA) *ColorRenderParameter {
+	return &ColorRenderParameter{
+		EmptyParameter: EmptyParameter{
+			Name: name,
+			Type: "color",
+		},
+		Value: value,
+	}
+}
+
+// NewChoiceRP creates a parameter restricted to choices. If value is not
+// among them, the first choice is used instead.
+func NewChoiceRP(name string, value string, choices ...string) *ChoiceRenderParameter {
+	p := &ChoiceRenderParameter{
+		EmptyParameter: EmptyParameter{
+			Name: name,
+			Type: "choice",
+		},
+		Choices: choices,
+	}
+	if len(choices) > 0 {
+		p.Value = choices[0]
+	}
+	p.SetValueString(value)
+	return p
+}
+
 // Utility functions
 
 // GetParameterValueAsString replaces the need to implement GetValueString on
@@ -245,13 +591,113 @@ func GetParameterValueAsString(p RenderParameter) string {
 		return fmt.Sprintf("%v", p.GetValueFloat64())
 	case "complex128":
 		return fmt.Sprintf("%v", p.GetValueComplex128())
-	case "string":
+	case "bool":
+		return strconv.FormatBool(p.GetValueBool())
+	case "color":
+		return FormatColor(p.GetValueColor())
+	case "string", "choice":
+		return p.GetValueString()
+	default:
 		return p.GetValueString()
+	}
+}
+
+// GetParameterLabel returns the name to show for p, its META_LABEL or else its name
+func GetParameterLabel(p RenderParameter) string {
+	if l := p.GetMeta(META_LABEL); l != "" {
+		return l
+	}
+	return p.GetName()
+}
+
+// IsReadOnly reports whether p is an output of the renderer, shown but
+// not edited, saved or restored
+func IsReadOnly(p RenderParameter) bool {
+	return p.GetHint()&HINT_READONLY != 0
+}
+
+// FormatParameterValue returns the value of p for display, formatting
+// numbers with its META_FORMAT if it has one. Unlike
+// GetParameterValueAsString it may round, so do not store the result.
+func FormatParameterValue(p RenderParameter) string {
+	format := p.GetMeta(META_FORMAT)
+	if format == "" {
+		return GetParameterValueAsString(p)
+	}
+	switch p.GetType() {
+	case "int":
+		return fmt.Sprintf(format, p.GetValueInt())
+	case "uint32":
+		return fmt.Sprintf(format, p.GetValueUInt32())
+	case "float64":
+		return fmt.Sprintf(format, p.GetValueFloat64())
+	case "complex128":
+		return fmt.Sprintf(format, p.GetValueComplex128())
+	}
+	return GetParameterValueAsString(p)
+}
+
+// GetParameterValue returns the value of a parameter in its native type:
+// int, uint32, float64, complex128, bool, color.RGBA, Point, string, or
+// a []int, []float64 or []string for lists
+func GetParameterValue(p RenderParameter) interface{} {
+	switch p.GetType() {
+	case "[]int", "[]float64", "[]string":
+		v, _ := parseListValue(p.GetType(), p.GetValueString())
+		return v
+	case "point":
+		pt, _ := ParsePoint(p.GetValueString())
+		return pt
+	case "int":
+		return p.GetValueInt()
+	case "uint32":
+		return p.GetValueUInt32()
+	case "float64":
+		return p.GetValueFloat64()
+	case "complex128":
+		return p.GetValueComplex128()
+	case "bool":
+		return p.GetValueBool()
+	case "color":
+		return p.GetValueColor()
 	default:
 		return p.GetValueString()
 	}
 }
 
+// GetParameterValueAsFloat64 reads any numeric parameter as a float64
+func GetParameterValueAsFloat64(p RenderParameter) float64 {
+	switch p.GetType() {
+	case "int":
+		return float64(p.GetValueInt())
+	case "uint32":
+		return float64(p.GetValueUInt32())
+	case "float64":
+		return p.GetValueFloat64()
+	case "complex128":
+		return real(p.GetValueComplex128())
+	default:
+		return 0
+	}
+}
+
+// SetParameterValueFromFloat64 sets any numeric parameter from a float64,
+// rounding for the integer types, and returns the value actually set
+func SetParameterValueFromFloat64(p RenderParameter, v float64) float64 {
+	switch p.GetType() {
+	case "int":
+		return float64(p.SetValueInt(int(math.Round(v))))
+	case "uint32":
+		return float64(p.SetValueUInt32(uint32(math.Round(v))))
+	case "float64":
+		return p.SetValueFloat64(v)
+	case "complex128":
+		return real(p.SetValueComplex128(complex(v, 0)))
+	default:
+		return 0
+	}
+}
+
 func ParseComplex(v string) (complex128, error) {
 	v = strings.Replace(v, ",", "+", -1)
 	l := strings.Split(v, "+")
@@ -272,36 +718,228 @@ func ParseComplex(v string) (complex128, error) {
 
 }
 
-// SetParameterValueAsString replaces the need to implement SetValueString on
-// each parameter. Custom parameters should override SetValueString to override
-// this behavior.
-func SetParameterValueFromString(p RenderParameter, v string) {
+// FormatColor writes a color as #rrggbb, or #rrggbbaa when it is not opaque
+func FormatColor(c color.RGBA) string {
+	if c.A == 255 {
+		return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+	}
+	return fmt.Sprintf("#%02x%02x%02x%02x", c.R, c.G, c.B, c.A)
+}
+
+// ParseColor accepts hex colors in the forms #rgb, #rrggbb and #rrggbbaa
+// (the # is optional), or decimal channels as r,g,b or r,g,b,a
+func ParseColor(v string) (color.RGBA, error) {
+	v = strings.TrimSpace(v)
+	if strings.Contains(v, ",") {
+		l := strings.Split(v, ",")
+		if len(l) != 3 && len(l) != 4 {
+			return color.RGBA{}, fmt.Errorf("expected 3 or 4 channels")
+		}
+		ch := []uint8{0, 0, 0, 255}
+		for i, s := range l {
+			n, err := strconv.ParseUint(strings.TrimSpace(s), 10, 8)
+			if err != nil {
+				return color.RGBA{}, err
+			}
+			ch[i] = uint8(n)
+		}
+		return color.RGBA{ch[0], ch[1], ch[2], ch[3]}, nil
+	}
+	v = strings.TrimPrefix(v, "#")
+	if len(v) == 3 {
+		v = string([]byte{v[0], v[0], v[1], v[1], v[2], v[2]})
+	}
+	if len(v) == 6 {
+		v = v + "ff"
+	}
+	if len(v) != 8 {
+		return color.RGBA{}, fmt.Errorf("expected #rgb, #rrggbb or #rrggbbaa")
+	}
+	n, err := strconv.ParseUint(v, 16, 32)
+	if err != nil {
+		return color.RGBA{}, err
+	}
+	return color.RGBA{uint8(n >> 24), uint8(n >> 16), uint8(n >> 8), uint8(n)}, nil
+}
+
+// SetParameterValueFromString parses v according to the type of p and,
+// if it parses and passes validation, stores it. On error p keeps its
+// previous value and a *ParseError is returned. int, float64 and
+// complex128 values may be written as expressions (see EvalExpression).
+func SetParameterValueFromString(p RenderParameter, v string) error {
+	var value interface{}
+	var err error
 	switch p.GetType() {
 	case "int":
-		i, err := strconv.Atoi(v)
-		if err == nil {
-			p.SetValueInt(i)
-		}
+		value, err = ParseIntExpression(v)
 	case "uint32":
-		i, err := strconv.ParseInt(v, 10, 32)
-		if err == nil {
-			p.SetValueUInt32(uint32(i))
-		}
+		var u uint64
+		u, err = strconv.ParseUint(v, 10, 32)
+		value = uint32(u)
 	case "float64":
-		f, err := strconv.ParseFloat(v, 64)
-		if err == nil {
-			p.SetValueFloat64(f)
+		var f float64
+		f, err = ParseFloatExpression(v)
+		if _, _, _, bounded := p.GetRange(); err == nil && bounded && math.IsInf(f, 0) {
+			err = fmt.Errorf("%v is out of range", v)
 		}
+		value = f
 	case "complex128":
-		c, err := ParseComplex(v)
-		if err == nil {
-			p.SetValueComplex128(c)
+		value, err = ParseComplexExpression(v)
+	case "bool":
+		value, err = strconv.ParseBool(v)
+	case "color":
+		value, err = ParseColor(v)
+	case "[]int", "[]float64", "[]string":
+		value, err = parseListValue(p.GetType(), v)
+	case "point":
+		value, err = ParsePoint(v)
+	case "choice":
+		value = v
+		err = fmt.Errorf("expected one of %v", strings.Join(p.GetChoices(), ", "))
+		for _, c := range p.GetChoices() {
+			if c == v {
+				err = nil
+				break
+			}
 		}
-	case "string":
-		p.SetValueString(v)
 	default:
-		p.SetValueString(v)
+		value = v
+	}
+	if ne, ok := err.(*strconv.NumError); ok {
+		err = ne.Err
+	}
+	if err == nil {
+		err = p.Validate(value)
+	}
+	if err != nil {
+		return &ParseError{Name: p.GetName(), Input: v, Err: err}
+	}
+	return SetParameterValue(p, value)
+}
+
+// SetParameterValue sets p from v, which is in the parameter's native
+// type (see GetParameterValue), except that an int, uint32 or float64
+// sets any numeric parameter, rounding for the integer types
+func SetParameterValue(p RenderParameter, v interface{}) error {
+	t := p.GetType()
+	switch v := v.(type) {
+	case int:
+		if t == "int" {
+			p.SetValueInt(v)
+			return nil
+		}
+		return setNumericParameter(p, float64(v))
+	case uint32:
+		if t == "uint32" {
+			p.SetValueUInt32(v)
+			return nil
+		}
+		return setNumericParameter(p, float64(v))
+	case float64:
+		if t == "float64" {
+			p.SetValueFloat64(v)
+			return nil
+		}
+		return setNumericParameter(p, v)
+	case complex128:
+		if t == "complex128" {
+			p.SetValueComplex128(v)
+			return nil
+		}
+	case bool:
+		if t == "bool" {
+			p.SetValueBool(v)
+			return nil
+		}
+	case color.RGBA:
+		if t == "color" {
+			p.SetValueColor(v)
+			return nil
+		}
+	case []int:
+		if t == "[]int" {
+			p.SetValueString(FormatList(formatList(v)))
+			return nil
+		}
+	case []float64:
+		if t == "[]float64" {
+			p.SetValueString(FormatList(formatList(v)))
+			return nil
+		}
+	case []string:
+		if t == "[]string" {
+			p.SetValueString(FormatList(v))
+			return nil
+		}
+	case Point:
+		if t == "point" {
+			p.SetValueString(FormatPoint(v))
+			return nil
+		}
+	case string:
+		switch t {
+		case "int", "uint32", "float64", "complex128", "bool", "color", "point", "[]int", "[]float64", "[]string":
+		default:
+			p.SetValueString(v)
+			return nil
+		}
+	}
+	return fmt.Errorf("%v: cannot set %v parameter from %T", p.GetName(), t, v)
+}
+
+func setNumericParameter(p RenderParameter, v float64) error {
+	switch p.GetType() {
+	case "int", "uint32", "float64", "complex128":
+		SetParameterValueFromFloat64(p, v)
+		return nil
+	}
+	return fmt.Errorf("%v: cannot set %v parameter from a number", p.GetName(), p.GetType())
+}
 
+// ValidateFunc checks a value, in the parameter's native type, before
+// SetParameterValueFromString stores it; a non-nil error rejects the value
+type ValidateFunc func(v interface{}) error
+
+// SetValidator installs f to check values entered by the user
+func (e *EmptyParameter) SetValidator(f ValidateFunc) {
+	e.validator = f
+}
+
+// Validate runs the validator, if any, against v
+func (e *EmptyParameter) Validate(v interface{}) error {
+	if e.validator == nil {
+		return nil
+	}
+	return e.validator(v)
+}
+
+// ParseError reports input that could not be stored in a parameter,
+// either because it did not parse or because the validator rejected it
+type ParseError struct {
+	Name  string
+	Input string
+	Err   error
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("%v: cannot use %q: %v", e.Name, e.Input, e.Err)
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// NotifyChannel arranges for each of params to be sent on ch whenever its
+// value changes. Sends do not block; if ch is full the notification is dropped.
+func NotifyChannel(ch chan<- RenderParameter, params ...RenderParameter) {
+	for _, p := range params {
+		p := p
+		p.OnChange(func(oldValue interface{}, newValue interface{}) {
+			select {
+			case ch <- p:
+			default:
+			}
+		})
 	}
 }
 
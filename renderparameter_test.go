@@ -0,0 +1,50 @@
+// Copyright 2020 Howard C. Shaw III. All rights reserved.
+// Use of this source code is governed by the MIT-license
+// as defined in the LICENSE file.
+
+package renderview
+
+import (
+	"math"
+	"testing"
+)
+
+func TestBoundedIntStaysOnStep(t *testing.T) {
+	p := NewIntRP("n", 0)
+	p.Bounded, p.Min, p.Max, p.Step = true, 0, 10, 3
+	tests := []struct{ in, want int }{
+		{-4, 0},
+		{4, 3},
+		{5, 6},
+		{9, 9},
+		{10, 9},
+		{100, 9},
+	}
+	for _, tt := range tests {
+		if got := p.SetValueInt(tt.in); got != tt.want {
+			t.Errorf("SetValueInt(%d) = %d, want %d", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestBoundedFloatStaysOnStep(t *testing.T) {
+	p := NewFloat64RP("f", 0)
+	p.Bounded, p.Min, p.Max, p.Step = true, 0, 1, 0.3
+	tests := []struct{ in, want float64 }{
+		{-1, 0},
+		{0.5, 0.6},
+		{1, 0.9},
+		{5, 0.9},
+	}
+	for _, tt := range tests {
+		if got := p.SetValueFloat64(tt.in); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("SetValueFloat64(%v) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+
+	// Max on the grid stays reachable despite rounding in the division
+	p.Max, p.Step = 1, 0.1
+	if got := p.SetValueFloat64(1); math.Abs(got-1) > 1e-9 {
+		t.Errorf("SetValueFloat64(1) with step 0.1 = %v, want 1", got)
+	}
+}
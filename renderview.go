@@ -6,8 +6,11 @@ package renderview
 
 const (
 	OPT_NONE        = iota      // 0
-	OPT_CENTER_ZOOM = 1 << iota // 1
-	OPT_AUTO_ZOOM   = 1 << iota // 2
+	OPT_CENTER_ZOOM = 1 << iota // 2
+	OPT_AUTO_ZOOM   = 1 << iota // 4
+	// OPT_RESTORE_SESSION saves the parameters when the window closes
+	// and restores them the next time the program starts
+	OPT_RESTORE_SESSION = 1 << iota // 8
 )
 
 const ZOOM_RATE = 0.1
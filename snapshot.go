@@ -0,0 +1,146 @@
+// Copyright 2020 Howard C. Shaw III. All rights reserved.
+// Use of this source code is governed by the MIT-license
+// as defined in the LICENSE file.
+
+package renderview
+
+import (
+	"image/color"
+	"math"
+)
+
+// Snapshot holds the values of a model's parameters as they were at one
+// moment, taken under the model lock so they are consistent with each
+// other; a render reading a Snapshot cannot see a viewport half way
+// through a drag. A Snapshot never changes and may be shared between
+// goroutines.
+type Snapshot struct {
+	names  []string
+	values map[string]interface{}
+	texts  map[string]string
+}
+
+// TakeSnapshot locks m and copies the values of all of its parameters
+func TakeSnapshot(m RenderModel) Snapshot {
+	m.Lock()
+	defer m.Unlock()
+	return snapshotOf(m)
+}
+
+// snapshotOf copies the parameters of m; the caller holds the model lock
+func snapshotOf(m RenderModel) Snapshot {
+	names := m.GetParameterNames()
+	s := Snapshot{
+		names:  names,
+		values: make(map[string]interface{}, len(names)),
+		texts:  make(map[string]string, len(names)),
+	}
+	for _, name := range names {
+		p := m.GetParameter(name)
+		s.values[name] = GetParameterValue(p)
+		s.texts[name] = GetParameterValueAsString(p)
+	}
+	return s
+}
+
+// Names returns the names of the parameters in the snapshot, in the
+// model's order
+func (s Snapshot) Names() []string {
+	names := make([]string, len(s.names))
+	copy(names, s.names)
+	return names
+}
+
+// Has reports whether the snapshot holds the named parameter
+func (s Snapshot) Has(name string) bool {
+	_, ok := s.values[name]
+	return ok
+}
+
+// Value returns the named parameter's value in its native type (see
+// GetParameterValue), or nil if there is no such parameter
+func (s Snapshot) Value(name string) interface{} {
+	return s.values[name]
+}
+
+// String returns the named parameter's value as GetParameterValueAsString
+// writes it, whatever its type
+func (s Snapshot) String(name string) string {
+	return s.texts[name]
+}
+
+// Float64 returns the value of a numeric parameter as a float64, taking
+// the real part of a complex128, or 0 for any other parameter
+func (s Snapshot) Float64(name string) float64 {
+	switch v := s.values[name].(type) {
+	case int:
+		return float64(v)
+	case uint32:
+		return float64(v)
+	case float64:
+		return v
+	case complex128:
+		return real(v)
+	}
+	return 0
+}
+
+// Int returns the value of a numeric parameter as an int, rounding
+// floating point values, or 0 for any other parameter
+func (s Snapshot) Int(name string) int {
+	if v, ok := s.values[name].(int); ok {
+		return v
+	}
+	return int(math.Round(s.Float64(name)))
+}
+
+// UInt32 returns the value of a uint32 parameter, or 0 for any other
+func (s Snapshot) UInt32(name string) uint32 {
+	v, _ := s.values[name].(uint32)
+	return v
+}
+
+// Complex128 returns the value of a numeric parameter as a complex128,
+// or 0 for any other parameter
+func (s Snapshot) Complex128(name string) complex128 {
+	if v, ok := s.values[name].(complex128); ok {
+		return v
+	}
+	return complex(s.Float64(name), 0)
+}
+
+// Bool returns the value of a bool parameter, or false for any other
+func (s Snapshot) Bool(name string) bool {
+	v, _ := s.values[name].(bool)
+	return v
+}
+
+// Point returns the value of a point parameter, or the origin for any other
+func (s Snapshot) Point(name string) Point {
+	v, _ := s.values[name].(Point)
+	return v
+}
+
+// Ints returns a copy of the value of an []int parameter, or nil for any other
+func (s Snapshot) Ints(name string) []int {
+	v, _ := s.values[name].([]int)
+	return append([]int(nil), v...)
+}
+
+// Float64s returns a copy of the value of a []float64 parameter, or nil for any other
+func (s Snapshot) Float64s(name string) []float64 {
+	v, _ := s.values[name].([]float64)
+	return append([]float64(nil), v...)
+}
+
+// Strings returns a copy of the value of a []string parameter, or nil for any other
+func (s Snapshot) Strings(name string) []string {
+	v, _ := s.values[name].([]string)
+	return append([]string(nil), v...)
+}
+
+// Color returns the value of a color parameter, or transparent black for any other
+func (s Snapshot) Color(name string) color.RGBA {
+	v, _ := s.values[name].(color.RGBA)
+	return v
+}
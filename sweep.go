@@ -0,0 +1,237 @@
+// Copyright 2020 Howard C. Shaw III. All rights reserved.
+// Use of this source code is governed by the MIT-license
+// as defined in the LICENSE file.
+
+package renderview
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+)
+
+// SweepAxis is a numeric parameter of a sweep and the values it takes,
+// From to To inclusive in increments of Step
+type SweepAxis struct {
+	Name           string
+	From, To, Step float64
+}
+
+// ParseSweepAxis reads an axis written as name=from:to:step, where each
+// number may be an expression, as for float64 parameters
+func ParseSweepAxis(s string) (SweepAxis, error) {
+	l := strings.SplitN(s, "=", 2)
+	if len(l) != 2 || strings.TrimSpace(l[0]) == "" {
+		return SweepAxis{}, fmt.Errorf("%q: expected name=from:to:step", s)
+	}
+	a := SweepAxis{Name: strings.TrimSpace(l[0])}
+	r := strings.Split(l[1], ":")
+	if len(r) != 3 {
+		return SweepAxis{}, fmt.Errorf("%q: expected name=from:to:step", s)
+	}
+	for i, f := range []*float64{&a.From, &a.To, &a.Step} {
+		v, err := ParseFloatExpression(strings.TrimSpace(r[i]))
+		if err != nil {
+			return SweepAxis{}, fmt.Errorf("%q: %v", s, err)
+		}
+		*f = v
+	}
+	return a, a.check()
+}
+
+func (a SweepAxis) check() error {
+	if a.Step == 0 || math.IsNaN(a.Step) || (a.To-a.From)/a.Step < 0 {
+		return fmt.Errorf("%v: a step of %v does not lead from %v to %v", a.Name, a.Step, a.From, a.To)
+	}
+	return nil
+}
+
+// Values returns the values the axis takes, in order
+func (a SweepAxis) Values() []float64 {
+	if a.check() != nil {
+		return nil
+	}
+	n := int(math.Floor((a.To-a.From)/a.Step+1e-9)) + 1
+	v := make([]float64, n)
+	for i := range v {
+		v[i] = a.From + float64(i)*a.Step
+	}
+	return v
+}
+
+// Sweep renders a model over every combination of the values of one or
+// two parameters
+type Sweep struct {
+	// Axes are the parameters swept, one or two. On the contact sheet
+	// the first axis selects the row, the second the column.
+	Axes []SweepAxis
+	// Dir, if set, receives a PNG per combination, named for its values
+	// like maxEsc=50_angle=60.png
+	Dir string
+	// Sheet, if set, is the file the contact sheet is written to as a PNG
+	Sheet string
+}
+
+// Each sets the model to each combination of values in turn, the last
+// axis varying fastest, and hands the values and rendered image to f,
+// stopping at the first error. The parameters are restored afterwards.
+// The images are the size of the model's width and height parameters, so
+// set them first when there is no view to do so.
+func (s *Sweep) Each(m RenderModel, f func(values []float64, img image.Image) error) error {
+	if len(s.Axes) < 1 || len(s.Axes) > 2 {
+		return fmt.Errorf("a sweep takes one or two parameters, not %d", len(s.Axes))
+	}
+	params := make([]RenderParameter, len(s.Axes))
+	axisValues := make([][]float64, len(s.Axes))
+	m.Lock()
+	for i, a := range s.Axes {
+		params[i] = m.GetParameter(a.Name)
+		if params[i].GetName() != a.Name {
+			m.Unlock()
+			return fmt.Errorf("%v: no such parameter", a.Name)
+		}
+		switch params[i].GetType() {
+		case "int", "uint32", "float64":
+		default:
+			m.Unlock()
+			return fmt.Errorf("%v: cannot sweep a %v parameter", a.Name, params[i].GetType())
+		}
+		if err := a.check(); err != nil {
+			m.Unlock()
+			return err
+		}
+		axisValues[i] = a.Values()
+	}
+	m.Unlock()
+	defer headless(m)()
+
+	var values []float64
+	var sweep func(axis int) error
+	sweep = func(axis int) error {
+		if axis == len(params) {
+			img, err := renderNow(m)
+			if err == nil {
+				err = f(append([]float64(nil), values...), img)
+			}
+			if err != nil {
+				return fmt.Errorf("%v: %v", s.label(values, " "), err)
+			}
+			return nil
+		}
+		for _, v := range axisValues[axis] {
+			m.Lock()
+			err := SetParameterValueFromString(params[axis], formatNumber(params[axis].GetType(), v))
+			m.Unlock()
+			if err != nil {
+				return fmt.Errorf("%v: %v", s.Axes[axis].Name, err)
+			}
+			values = append(values[:axis], v)
+			if err = sweep(axis + 1); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return sweep(0)
+}
+
+// Run renders every combination, writing the images to Dir and the
+// contact sheet to Sheet, whichever are set
+func (s *Sweep) Run(m RenderModel) error {
+	if s.Dir != "" {
+		if err := os.MkdirAll(s.Dir, 0755); err != nil {
+			return err
+		}
+	}
+	var images []image.Image
+	var labels []string
+	err := s.Each(m, func(values []float64, img image.Image) error {
+		if s.Dir != "" {
+			name := filepath.Join(s.Dir, s.label(values, "_")+".png")
+			if err := writePNG(name, img); err != nil {
+				return err
+			}
+		}
+		if s.Sheet != "" {
+			images = append(images, img)
+			labels = append(labels, s.label(values, " "))
+		}
+		return nil
+	})
+	if err != nil || s.Sheet == "" {
+		return err
+	}
+	columns := 1
+	if len(s.Axes) > 1 {
+		columns = len(s.Axes[1].Values())
+	}
+	return writePNG(s.Sheet, ContactSheet(columns, images, labels))
+}
+
+// label writes values as name=value pairs separated by sep
+func (s *Sweep) label(values []float64, sep string) string {
+	l := make([]string, len(values))
+	for i, v := range values {
+		l[i] = s.Axes[i].Name + "=" + strconv.FormatFloat(v, 'g', 6, 64)
+	}
+	return strings.Join(l, sep)
+}
+
+// CONTACT_SHEET_PADDING is the space in pixels around each image of a
+// contact sheet
+const CONTACT_SHEET_PADDING = 4
+
+// ContactSheet lays the images out in a grid with the given number of
+// columns on a white background, each labeled beneath. The cells are the
+// size of the largest image, widened if need be to fit the labels.
+func ContactSheet(columns int, images []image.Image, labels []string) *image.RGBA {
+	if columns < 1 {
+		columns = 1
+	}
+	face := basicfont.Face7x13
+	lineHeight := face.Metrics().Height.Ceil()
+	var cell image.Point
+	for _, img := range images {
+		size := img.Bounds().Size()
+		if size.X > cell.X {
+			cell.X = size.X
+		}
+		if size.Y > cell.Y {
+			cell.Y = size.Y
+		}
+	}
+	for _, label := range labels {
+		if w := font.MeasureString(face, label).Ceil(); w > cell.X {
+			cell.X = w
+		}
+	}
+	pitch := image.Pt(cell.X+CONTACT_SHEET_PADDING, cell.Y+lineHeight+CONTACT_SHEET_PADDING)
+	rows := (len(images) + columns - 1) / columns
+	sheet := image.NewRGBA(image.Rect(0, 0,
+		columns*pitch.X+CONTACT_SHEET_PADDING, rows*pitch.Y+CONTACT_SHEET_PADDING))
+	draw.Draw(sheet, sheet.Bounds(), image.White, image.ZP, draw.Src)
+	d := font.Drawer{
+		Dst:  sheet,
+		Src:  image.NewUniform(color.Black),
+		Face: face,
+	}
+	for i, img := range images {
+		at := image.Pt(CONTACT_SHEET_PADDING+(i%columns)*pitch.X, CONTACT_SHEET_PADDING+(i/columns)*pitch.Y)
+		draw.Draw(sheet, image.Rectangle{at, at.Add(img.Bounds().Size())}, img, img.Bounds().Min, draw.Src)
+		if i < len(labels) {
+			d.Dot = fixed.P(at.X, at.Y+cell.Y+face.Metrics().Ascent.Ceil())
+			d.DrawString(labels[i])
+		}
+	}
+	return sheet
+}
@@ -0,0 +1,529 @@
+// Copyright 2020 Howard C. Shaw III. All rights reserved.
+// Use of this source code is governed by the MIT-license
+// as defined in the LICENSE file.
+
+package renderview
+
+import (
+	"encoding/json"
+	"fmt"
+	"image/color"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Interpolation says how a parameter moves from one keyframe to the next
+type Interpolation int
+
+const (
+	// INTERP_LINEAR moves numbers, complex numbers, colors and points at
+	// an even pace; other types change at the next keyframe
+	INTERP_LINEAR Interpolation = iota
+	// INTERP_EXPONENTIAL moves a number geometrically, as long as both
+	// ends have the same sign. For left and right, or top and bottom, the
+	// centre of the span moves linearly and its size geometrically, so a
+	// zoom proceeds at an even pace.
+	INTERP_EXPONENTIAL
+	// INTERP_STEP holds the value until the next keyframe
+	INTERP_STEP
+)
+
+var interpolationNames = []string{"linear", "exponential", "step"}
+
+func (i Interpolation) String() string {
+	if i < 0 || int(i) >= len(interpolationNames) {
+		return fmt.Sprintf("Interpolation(%d)", int(i))
+	}
+	return interpolationNames[i]
+}
+
+// ParseInterpolation reads the names linear, exponential and step
+func ParseInterpolation(s string) (Interpolation, error) {
+	for i, name := range interpolationNames {
+		if s == name {
+			return Interpolation(i), nil
+		}
+	}
+	return INTERP_LINEAR, fmt.Errorf("unknown interpolation %q, expected linear, exponential or step", s)
+}
+
+func (i Interpolation) MarshalText() ([]byte, error) {
+	return []byte(i.String()), nil
+}
+
+func (i *Interpolation) UnmarshalText(text []byte) error {
+	v, err := ParseInterpolation(string(text))
+	*i = v
+	return err
+}
+
+// Keyframe is the state of the parameters at a moment of an animation
+type Keyframe struct {
+	// Time is in seconds from the start of the animation
+	Time   float64          `json:"time"`
+	Params []SavedParameter `json:"params"`
+}
+
+// Timeline animates a model's parameters through a list of keyframes.
+// Between keyframes each parameter is interpolated as set with
+// SetInterpolation; the viewport edges default to INTERP_EXPONENTIAL and
+// everything else to INTERP_LINEAR.
+//
+// Play starts playing the timeline in the live view, which the drivers
+// advance by calling Step from a timer; Export renders it headlessly.
+type Timeline struct {
+	sync.Mutex
+
+	// FPS is the frame rate of export and the most playback will show
+	FPS float64
+	// Loop restarts playback from the first keyframe when it reaches the last
+	Loop bool
+
+	model     RenderModel
+	keys      []Keyframe
+	interp    map[string]Interpolation
+	listeners []func()
+	playing   bool
+	start     time.Time
+}
+
+// NewTimeline creates an empty timeline for m playing at 25 frames a second
+func NewTimeline(m RenderModel) *Timeline {
+	t := &Timeline{
+		FPS:    25,
+		model:  m,
+		interp: make(map[string]Interpolation),
+	}
+	for _, name := range viewParameters[1:] {
+		t.interp[name] = INTERP_EXPONENTIAL
+	}
+	return t
+}
+
+// OnChange registers f to be called after keyframes are added or removed
+func (t *Timeline) OnChange(f func()) {
+	t.Lock()
+	defer t.Unlock()
+	t.listeners = append(t.listeners, f)
+}
+
+func (t *Timeline) notify() {
+	t.Lock()
+	listeners := t.listeners
+	t.Unlock()
+	for _, f := range listeners {
+		f()
+	}
+}
+
+// SetInterpolation sets how the named parameter moves between keyframes
+func (t *Timeline) SetInterpolation(name string, i Interpolation) {
+	t.Lock()
+	defer t.Unlock()
+	t.interp[name] = i
+}
+
+// GetInterpolation returns how the named parameter moves between keyframes
+func (t *Timeline) GetInterpolation(name string) Interpolation {
+	t.Lock()
+	defer t.Unlock()
+	return t.interp[name]
+}
+
+// AddKeyframe records the current parameters as the keyframe at time
+// at, in seconds, replacing any keyframe already there
+func (t *Timeline) AddKeyframe(at float64) error {
+	if at < 0 || math.IsNaN(at) || math.IsInf(at, 0) {
+		return fmt.Errorf("keyframe time must be 0 or more, not %v", at)
+	}
+	t.Lock()
+	t.model.Lock()
+	k := Keyframe{Time: at, Params: parameterState(t.model)}
+	t.model.Unlock()
+	i := sort.Search(len(t.keys), func(i int) bool { return t.keys[i].Time >= at })
+	if i < len(t.keys) && t.keys[i].Time == at {
+		t.keys[i] = k
+	} else {
+		t.keys = append(t.keys, Keyframe{})
+		copy(t.keys[i+1:], t.keys[i:])
+		t.keys[i] = k
+	}
+	t.Unlock()
+	t.notify()
+	return nil
+}
+
+// AppendKeyframe records the current parameters as a keyframe gap
+// seconds after the last one, or at the start if there are none
+func (t *Timeline) AppendKeyframe(gap float64) error {
+	at := 0.0
+	if len(t.Keyframes()) > 0 {
+		at = t.Duration() + gap
+	}
+	return t.AddKeyframe(at)
+}
+
+// RemoveKeyframe deletes the i'th keyframe, stopping playback if fewer
+// than two are left
+func (t *Timeline) RemoveKeyframe(i int) {
+	t.Lock()
+	if i < 0 || i >= len(t.keys) {
+		t.Unlock()
+		return
+	}
+	t.keys = append(t.keys[:i], t.keys[i+1:]...)
+	if len(t.keys) < 2 {
+		t.playing = false
+	}
+	t.Unlock()
+	t.notify()
+}
+
+// Clear deletes all the keyframes and stops playback
+func (t *Timeline) Clear() {
+	t.Lock()
+	t.keys = nil
+	t.playing = false
+	t.Unlock()
+	t.notify()
+}
+
+// Keyframes returns a copy of the keyframes in order of time
+func (t *Timeline) Keyframes() []Keyframe {
+	t.Lock()
+	defer t.Unlock()
+	return append([]Keyframe(nil), t.keys...)
+}
+
+// Duration returns the time of the last keyframe
+func (t *Timeline) Duration() float64 {
+	t.Lock()
+	defer t.Unlock()
+	if len(t.keys) == 0 {
+		return 0
+	}
+	return t.keys[len(t.keys)-1].Time
+}
+
+// Frames returns the number of frames Export renders, one every 1/FPS
+// seconds from the first keyframe to the last
+func (t *Timeline) Frames() int {
+	t.Lock()
+	defer t.Unlock()
+	if len(t.keys) == 0 {
+		return 0
+	}
+	return int(math.Floor((t.keys[len(t.keys)-1].Time-t.keys[0].Time)*t.FPS+1e-9)) + 1
+}
+
+// StateAt returns the parameters as they stand at time at, in seconds,
+// in the form SaveParameters writes them
+func (t *Timeline) StateAt(at float64) []SavedParameter {
+	t.Lock()
+	defer t.Unlock()
+	return t.stateAt(at)
+}
+
+// ApplyAt sets the model's parameters to the state at time at
+func (t *Timeline) ApplyAt(at float64) error {
+	state := t.StateAt(at)
+	t.model.Lock()
+	defer t.model.Unlock()
+	return applyState(t.model, state)
+}
+
+// stateAt interpolates between the keyframes either side of at; the
+// caller holds the lock
+func (t *Timeline) stateAt(at float64) []SavedParameter {
+	n := len(t.keys)
+	if n == 0 {
+		return nil
+	}
+	if n == 1 || at <= t.keys[0].Time {
+		return append([]SavedParameter(nil), t.keys[0].Params...)
+	}
+	if at >= t.keys[n-1].Time {
+		return append([]SavedParameter(nil), t.keys[n-1].Params...)
+	}
+	k := sort.Search(n, func(i int) bool { return t.keys[i].Time > at }) - 1
+	a, b := t.keys[k], t.keys[k+1]
+	u := (at - a.Time) / (b.Time - a.Time)
+	from := savedByName(a.Params)
+	to := savedByName(b.Params)
+	state := make([]SavedParameter, 0, len(a.Params))
+	for _, s := range a.Params {
+		e, ok := to[s.Name]
+		if !ok || e.Type != s.Type {
+			state = append(state, s)
+			continue
+		}
+		switch t.interp[s.Name] {
+		case INTERP_STEP:
+		case INTERP_EXPONENTIAL:
+			if v, ok := interpolateEdge(s, from, to, u); ok {
+				s.Value = v
+			} else {
+				s.Value = interpolateSaved(s, e, u, true)
+			}
+		default:
+			s.Value = interpolateSaved(s, e, u, false)
+		}
+		state = append(state, s)
+	}
+	return state
+}
+
+func savedByName(params []SavedParameter) map[string]SavedParameter {
+	m := make(map[string]SavedParameter, len(params))
+	for _, s := range params {
+		m[s.Name] = s
+	}
+	return m
+}
+
+// spanPartners pairs each viewport edge with the opposite one
+var spanPartners = map[string]string{
+	"left":   "right",
+	"right":  "left",
+	"top":    "bottom",
+	"bottom": "top",
+}
+
+// interpolateEdge moves a viewport edge as part of its span, failing if
+// the edge is not one or its partner is missing from either keyframe
+func interpolateEdge(s SavedParameter, from, to map[string]SavedParameter, u float64) (string, bool) {
+	partner, ok := spanPartners[s.Name]
+	if !ok {
+		return "", false
+	}
+	var v [4]float64
+	for i, p := range []SavedParameter{from[s.Name], from[partner], to[s.Name], to[partner]} {
+		f, err := strconv.ParseFloat(p.Value, 64)
+		if err != nil {
+			return "", false
+		}
+		v[i] = f
+	}
+	// interpolateSpan wants the low edge first
+	low := s.Name == "left" || s.Name == "top"
+	if !low {
+		v[0], v[1], v[2], v[3] = v[1], v[0], v[3], v[2]
+	}
+	a, b := interpolateSpan(v[0], v[1], v[2], v[3], u)
+	if !low {
+		a = b
+	}
+	return formatNumber(s.Type, a), true
+}
+
+// interpolateSaved moves a value a fraction u of the way from s to e,
+// geometrically if asked and the ends allow it. Types with no notion
+// of in between keep the value of s.
+func interpolateSaved(s SavedParameter, e SavedParameter, u float64, geometric bool) string {
+	switch s.Type {
+	case "int", "uint32", "float64":
+		a, err1 := strconv.ParseFloat(s.Value, 64)
+		b, err2 := strconv.ParseFloat(e.Value, 64)
+		if err1 != nil || err2 != nil {
+			return s.Value
+		}
+		if geometric && a*b > 0 {
+			return formatNumber(s.Type, a*math.Pow(b/a, u))
+		}
+		return formatNumber(s.Type, lerp(a, b, u))
+	case "complex128":
+		a, err1 := ParseComplexExpression(s.Value)
+		b, err2 := ParseComplexExpression(e.Value)
+		if err1 != nil || err2 != nil {
+			return s.Value
+		}
+		return fmt.Sprintf("%v", complex(lerp(real(a), real(b), u), lerp(imag(a), imag(b), u)))
+	case "color":
+		a, err1 := ParseColor(s.Value)
+		b, err2 := ParseColor(e.Value)
+		if err1 != nil || err2 != nil {
+			return s.Value
+		}
+		ch := func(x, y uint8) uint8 {
+			return uint8(math.Round(lerp(float64(x), float64(y), u)))
+		}
+		return FormatColor(color.RGBA{ch(a.R, b.R), ch(a.G, b.G), ch(a.B, b.B), ch(a.A, b.A)})
+	case "point":
+		a, err1 := ParsePoint(s.Value)
+		b, err2 := ParsePoint(e.Value)
+		if err1 != nil || err2 != nil {
+			return s.Value
+		}
+		return FormatPoint(Point{lerp(a.X, b.X, u), lerp(a.Y, b.Y, u)})
+	}
+	return s.Value
+}
+
+func lerp(a, b, u float64) float64 {
+	return a + (b-a)*u
+}
+
+// formatNumber writes v as GetParameterValueAsString would for a
+// parameter of type typ, rounding for the integer types
+func formatNumber(typ string, v float64) string {
+	switch typ {
+	case "int":
+		return strconv.Itoa(int(math.Round(v)))
+	case "uint32":
+		return strconv.FormatUint(uint64(math.Round(math.Max(v, 0))), 10)
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+// Play starts playing the timeline from its first keyframe. The state
+// before playing is recorded in the model's history.
+func (t *Timeline) Play() error {
+	if len(t.Keyframes()) == 0 {
+		return fmt.Errorf("the timeline has no keyframes")
+	}
+	if err := checkFPS(t.frameRate()); err != nil {
+		return err
+	}
+	t.model.GetHistory().Checkpoint("animation")
+	t.Lock()
+	defer t.Unlock()
+	t.playing = true
+	t.start = time.Now()
+	return nil
+}
+
+// Stop stops playback where it is
+func (t *Timeline) Stop() {
+	t.Lock()
+	defer t.Unlock()
+	t.playing = false
+}
+
+// Interval returns the time between frames of playback, at least a
+// millisecond
+func (t *Timeline) Interval() time.Duration {
+	d := time.Duration(float64(time.Second) / t.frameRate())
+	if !(d >= time.Millisecond) {
+		d = time.Millisecond
+	}
+	return d
+}
+
+func (t *Timeline) frameRate() float64 {
+	t.Lock()
+	defer t.Unlock()
+	return t.FPS
+}
+
+// checkFPS rejects frame rates that are not positive and finite
+func checkFPS(fps float64) error {
+	if !(fps > 0) || math.IsInf(fps, 1) {
+		return fmt.Errorf("invalid frame rate %v", fps)
+	}
+	return nil
+}
+
+// Playing reports whether the timeline is playing
+func (t *Timeline) Playing() bool {
+	t.Lock()
+	defer t.Unlock()
+	return t.playing
+}
+
+// Step sets the parameters to where playback should be by now, reporting
+// whether it changed anything. Unless Loop is set, playback stops after
+// the step reaching the last keyframe.
+func (t *Timeline) Step() bool {
+	t.Lock()
+	defer t.Unlock()
+	if !t.playing || len(t.keys) == 0 {
+		t.playing = false
+		return false
+	}
+	first := t.keys[0].Time
+	length := t.keys[len(t.keys)-1].Time - first
+	at := time.Since(t.start).Seconds()
+	if at >= length {
+		if t.Loop && length > 0 {
+			at = math.Mod(at, length)
+		} else {
+			at = length
+			t.playing = false
+		}
+	}
+	state := t.stateAt(first + at)
+	t.model.Lock()
+	defer t.model.Unlock()
+	applyState(t.model, state)
+	return true
+}
+
+// timelineFile is the form in which Save writes a timeline
+type timelineFile struct {
+	FPS           float64                  `json:"fps"`
+	Interpolation map[string]Interpolation `json:"interpolation"`
+	Keyframes     []Keyframe               `json:"keyframes"`
+}
+
+// Save writes the keyframes, frame rate and interpolations to the named
+// file as JSON
+func (t *Timeline) Save(name string) error {
+	t.Lock()
+	data, err := json.MarshalIndent(timelineFile{
+		FPS:           t.FPS,
+		Interpolation: t.interp,
+		Keyframes:     t.keys,
+	}, "", "  ")
+	t.Unlock()
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(name, data, 0644)
+}
+
+// Load replaces the timeline with the one Save wrote to the named file
+func (t *Timeline) Load(name string) error {
+	data, err := ioutil.ReadFile(name)
+	if err != nil {
+		return err
+	}
+	var f timelineFile
+	if err = json.Unmarshal(data, &f); err != nil {
+		return fmt.Errorf("%v: %v", name, err)
+	}
+	// files without a frame rate keep the current one
+	if f.FPS != 0 {
+		if err = checkFPS(f.FPS); err != nil {
+			return fmt.Errorf("%v: %v", name, err)
+		}
+	}
+	sort.SliceStable(f.Keyframes, func(i, j int) bool {
+		return f.Keyframes[i].Time < f.Keyframes[j].Time
+	})
+	t.Lock()
+	if f.FPS != 0 {
+		t.FPS = f.FPS
+	}
+	for k, v := range f.Interpolation {
+		t.interp[k] = v
+	}
+	t.keys = f.Keyframes
+	t.playing = false
+	t.Unlock()
+	t.notify()
+	return nil
+}
+
+// DefaultTimelineFile returns the file name suggested for saving a
+// timeline, named after the program
+func DefaultTimelineFile() string {
+	name := strings.TrimSuffix(filepath.Base(os.Args[0]), filepath.Ext(os.Args[0]))
+	return name + ".timeline.json"
+}
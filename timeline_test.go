@@ -0,0 +1,65 @@
+// Copyright 2020 Howard C. Shaw III. All rights reserved.
+// Use of this source code is governed by the MIT-license
+// as defined in the LICENSE file.
+
+package renderview
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestTimelineRemoveKeyframeWhilePlaying(t *testing.T) {
+	m := NewBasicRenderModel()
+	m.AddParameters(NewFloat64RP("x", 0))
+	tl := m.GetTimeline()
+	if err := tl.AddKeyframe(0); err != nil {
+		t.Fatal(err)
+	}
+	if err := tl.AddKeyframe(1); err != nil {
+		t.Fatal(err)
+	}
+	if err := tl.Play(); err != nil {
+		t.Fatal(err)
+	}
+	tl.RemoveKeyframe(1)
+	if tl.Playing() {
+		t.Error("still playing with one keyframe left")
+	}
+	tl.RemoveKeyframe(0)
+	if tl.Step() {
+		t.Error("Step with no keyframes reported a change")
+	}
+}
+
+func TestTimelineFrameRate(t *testing.T) {
+	m := NewBasicRenderModel()
+	m.AddParameters(NewFloat64RP("x", 0))
+	tl := m.GetTimeline()
+	if err := tl.AddKeyframe(0); err != nil {
+		t.Fatal(err)
+	}
+	tl.FPS = 0
+	if err := tl.Play(); err == nil {
+		t.Error("Play accepted a frame rate of 0")
+	}
+
+	dir, err := ioutil.TempDir("", "timeline")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	name := filepath.Join(dir, "t.json")
+	if err = ioutil.WriteFile(name, []byte(`{"fps": -5, "keyframes": []}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	tl.FPS = 25
+	if err = tl.Load(name); err == nil {
+		t.Error("Load accepted a frame rate of -5")
+	}
+	if tl.FPS != 25 {
+		t.Errorf("FPS = %v after a failed Load, want 25", tl.FPS)
+	}
+}
@@ -0,0 +1,99 @@
+// Copyright 2020 Howard C. Shaw III. All rights reserved.
+// Use of this source code is governed by the MIT-license
+// as defined in the LICENSE file.
+
+package renderview
+
+// Viewport pans and zooms a model through its standard parameters (left,
+// top, right, bottom, width, height, zoom, zoomRate and options), taking
+// the coordinates as float64 whether the model declared them as ints or
+// floats. Missing parameters read as zero and ignore writes. Callers hold
+// the model lock, as for any parameter change.
+type Viewport struct {
+	Left, Top, Right, Bottom *Param[float64]
+	Width, Height            *Param[int]
+	Zoom, ZoomRate           *Param[float64]
+	Options                  *Param[int]
+}
+
+// NewViewport finds the viewport parameters of m
+func NewViewport(m RenderModel) *Viewport {
+	f := func(name string) *Param[float64] {
+		return &Param[float64]{m.GetParameter(name)}
+	}
+	i := func(name string) *Param[int] {
+		return &Param[int]{m.GetParameter(name)}
+	}
+	return &Viewport{
+		Left:     f("left"),
+		Top:      f("top"),
+		Right:    f("right"),
+		Bottom:   f("bottom"),
+		Width:    i("width"),
+		Height:   i("height"),
+		Zoom:     f("zoom"),
+		ZoomRate: f("zoomRate"),
+		Options:  i("options"),
+	}
+}
+
+// ZoomAt steps the zoom parameter in or out by one. With OPT_AUTO_ZOOM
+// the bounds shrink or grow by the zoom rate about the pixel x, y; with
+// OPT_CENTER_ZOOM, about the middle of the view.
+func (v *Viewport) ZoomAt(x, y float64, out bool) {
+	if out {
+		v.Zoom.Add(-1)
+	} else {
+		v.Zoom.Add(1)
+	}
+	options := v.Options.Get()
+	if options&(OPT_AUTO_ZOOM|OPT_CENTER_ZOOM) == 0 {
+		return
+	}
+	rate := ZOOM_RATE
+	if r := v.ZoomRate.Get(); r > 0 {
+		rate = r
+	}
+	mult := 1 - rate
+	if out {
+		mult = 1 + rate
+	}
+	cx := x / float64(v.Width.Get())
+	cy := y / float64(v.Height.Get())
+	if options&OPT_CENTER_ZOOM == OPT_CENTER_ZOOM {
+		cx = 0.5
+		cy = 0.5
+	}
+	zwidth := v.Right.Get() - v.Left.Get()
+	zheight := v.Bottom.Get() - v.Top.Get()
+	nzwidth := zwidth * mult
+	nzheight := zheight * mult
+	left := v.Left.Add(-(nzwidth - zwidth) * cx)
+	top := v.Top.Add(-(nzheight - zheight) * cy)
+	v.Right.Set(left + nzwidth)
+	v.Bottom.Set(top + nzheight)
+}
+
+// ToWorld maps the pixel x, y to the model's coordinates
+func (v *Viewport) ToWorld(x, y float64) (float64, float64) {
+	left, top := v.Left.Get(), v.Top.Get()
+	return left + x*(v.Right.Get()-left)/float64(v.Width.Get()),
+		top + y*(v.Bottom.Get()-top)/float64(v.Height.Get())
+}
+
+// ToPixel maps the model coordinates x, y to a pixel, the inverse of ToWorld
+func (v *Viewport) ToPixel(x, y float64) (float64, float64) {
+	left, top := v.Left.Get(), v.Top.Get()
+	return (x - left) * float64(v.Width.Get()) / (v.Right.Get() - left),
+		(y - top) * float64(v.Height.Get()) / (v.Bottom.Get() - top)
+}
+
+// Pan moves the bounds so the image follows a drag of dx, dy pixels
+func (v *Viewport) Pan(dx, dy float64) {
+	cx := dx * (v.Right.Get() - v.Left.Get()) / float64(v.Width.Get())
+	cy := dy * (v.Bottom.Get() - v.Top.Get()) / float64(v.Height.Get())
+	v.Left.Add(-cx)
+	v.Right.Add(-cx)
+	v.Top.Add(-cy)
+	v.Bottom.Add(-cy)
+}